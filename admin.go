@@ -0,0 +1,72 @@
+package yttracker
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+)
+
+func (tracker *MinerTracker) adminValidator(key string, c echo.Context) (bool, error) {
+	return subtle.ConstantTimeCompare([]byte(key), []byte(tracker.miscConfig().AdminToken)) == 1, nil
+}
+
+//ListAuthHandler list all accounts for authenticating MQ clients
+func (tracker *MinerTracker) ListAuthHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "ListAuthHandler"})
+	auths, err := ListAuth(context.Background(), tracker.dbCli)
+	if err != nil {
+		entry.WithError(err).Error("listing auth records")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, auths)
+}
+
+//AddAuthHandler add an account for authenticating MQ clients, permission can be specified by query param
+func (tracker *MinerTracker) AddAuthHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "AddAuthHandler"})
+	account := c.Param("account")
+	auth, err := AddAuth(context.Background(), tracker.eosAPI, tracker.dbCli, account, c.QueryParam("permission"))
+	if err != nil {
+		entry.WithField(AccountName, account).WithError(err).Error("adding auth record")
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	tracker.syncSvc.authCache.Invalidate(account)
+	return c.JSON(http.StatusOK, auth)
+}
+
+//RemoveAuthHandler remove an account for authenticating MQ clients
+func (tracker *MinerTracker) RemoveAuthHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "RemoveAuthHandler"})
+	account := c.Param("account")
+	err := RemoveAuth(context.Background(), tracker.dbCli, account)
+	if err != nil {
+		entry.WithField(AccountName, account).WithError(err).Error("removing auth record")
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	tracker.syncSvc.authCache.Invalidate(account)
+	return c.String(http.StatusOK, "success")
+}
+
+//RefreshAuthHandler refresh public key of one account, or all accounts if no account specified
+func (tracker *MinerTracker) RefreshAuthHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "RefreshAuthHandler"})
+	account := c.Param("account")
+	err := RefreshAuth(context.Background(), tracker.eosAPI, tracker.dbCli, account, tracker.miscConfig().RefreshAuthWorkers)
+	if err != nil {
+		entry.WithField(AccountName, account).WithError(err).Error("refreshing auth record")
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if account == "" {
+		err = tracker.syncSvc.authCache.Load(context.Background())
+		if err != nil {
+			entry.WithError(err).Error("reloading auth cache")
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	} else {
+		tracker.syncSvc.authCache.Invalidate(account)
+	}
+	return c.String(http.StatusOK, "success")
+}
@@ -0,0 +1,454 @@
+package yttracker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//AlertRuleTab collection name of alert rules managed in database
+var AlertRuleTab = "AlertRule"
+
+//types of alert rules
+const (
+	//AlertChange fires when value of field changes, optionally from and to specified values
+	AlertChange = "change"
+	//AlertIncrease fires when value of numeric field increases
+	AlertIncrease = "increase"
+	//AlertSilent fires when miner has not reported for specified minutes
+	AlertSilent = "silent"
+	//AlertRegister fires when a new miner is registered
+	AlertRegister = "register"
+	//AlertDelete fires when a miner is deleted
+	AlertDelete = "delete"
+)
+
+//SignatureHeader HTTP header of webhook request containing HMAC-SHA256 signature of request body
+const SignatureHeader = "X-Tracker-Signature"
+
+//count of alerts waiting for delivery to one webhook, alerts are dropped when queue is full
+const alertQueueSize = 1024
+
+//time(second) after which delivery worker of a webhook exits if no alert is fired to it
+const alertWorkerIdle = 600
+
+//AlertRule rule of alerting on miner state changes, rules can be defined in config file or alert rule collection
+type AlertRule struct {
+	//Name unique name of rule
+	Name string `mapstructure:"name" bson:"_id" json:"name"`
+	//Type one of change, increase, silent, register and delete
+	Type string `mapstructure:"type" bson:"type" json:"type"`
+	//Field name of miner field in database, used by change and increase rules
+	Field string `mapstructure:"field" bson:"field" json:"field"`
+	//From value before changing, any value if empty
+	From string `mapstructure:"from" bson:"from" json:"from"`
+	//To value after changing, any value if empty
+	To string `mapstructure:"to" bson:"to" json:"to"`
+	//Minutes time without report before silent rule fires
+	Minutes int `mapstructure:"minutes" bson:"minutes" json:"minutes"`
+	//RateLimit max count of alerts of this rule per minute, 0 means unlimited
+	RateLimit int `mapstructure:"rate-limit" bson:"rateLimit" json:"rateLimit"`
+	//Webhook URL receiving alerts of this rule, webhook-url of alert config is used if empty
+	Webhook string `mapstructure:"webhook" bson:"webhook" json:"webhook"`
+}
+
+//Alert content of webhook request
+type Alert struct {
+	Rule      string      `json:"rule"`
+	Type      string      `json:"type"`
+	MinerID   int32       `json:"minerID"`
+	PoolID    string      `json:"poolID"`
+	Field     string      `json:"field,omitempty"`
+	Old       interface{} `json:"old"`
+	New       interface{} `json:"new"`
+	Timestamp int64       `json:"timestamp"`
+	Instance  string      `json:"instance"`
+}
+
+//numeric returns value of integer or float field as float64
+func numeric(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+//Check check if rule is complete
+func (rule *AlertRule) Check() error {
+	if rule.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+	switch rule.Type {
+	case AlertChange, AlertIncrease:
+		v, ok := nodeField(new(Node), rule.Field)
+		if !ok {
+			return fmt.Errorf("no such miner field: %q", rule.Field)
+		}
+		if _, ok := numeric(v); rule.Type == AlertIncrease && !ok {
+			return fmt.Errorf("miner field %s is not numeric", rule.Field)
+		}
+	case AlertSilent:
+		if rule.Minutes <= 0 {
+			return fmt.Errorf("minutes must be positive, got %d", rule.Minutes)
+		}
+	case AlertRegister, AlertDelete:
+	default:
+		return fmt.Errorf("no such rule type: %q", rule.Type)
+	}
+	if rule.RateLimit < 0 {
+		return fmt.Errorf("rate-limit cannot be negative, got %d", rule.RateLimit)
+	}
+	if rule.Webhook != "" {
+		u, err := url.Parse(rule.Webhook)
+		if err != nil {
+			return fmt.Errorf("invalid webhook %q: %s", rule.Webhook, err)
+		}
+		if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("webhook %q must be a HTTP or HTTPS URL", rule.Webhook)
+		}
+	}
+	return nil
+}
+
+//rateWindow count of alerts fired in one minute
+type rateWindow struct {
+	minute int64
+	count  int
+}
+
+//alertDelivery one webhook request waiting for delivery
+type alertDelivery struct {
+	url  string
+	body []byte
+	sign string
+}
+
+//AlertManager evaluates alert rules on miner state changes and delivers alerts to webhooks with retry,
+//rules are evaluated only by leader so that each change is alerted once, each webhook has its own queue and
+//delivery worker, so that a slow or failing webhook does not delay alerts to others
+type AlertManager struct {
+	mongoCli   *mongo.Client
+	instanceID string
+	httpCli    *http.Client
+	lock       sync.Mutex
+	conf       *AlertConfig
+	dbRules    []*AlertRule
+	limits     map[string]*rateWindow
+	lastCheck  int64
+	sinks      map[string]chan *alertDelivery
+}
+
+//NewAlertManager create alert manager, timeout of webhook requests is set once here and does not change on reloading
+func NewAlertManager(cli *mongo.Client, instanceID string, conf *AlertConfig) *AlertManager {
+	httpCli := &http.Client{Timeout: time.Duration(conf.Timeout) * time.Second}
+	return &AlertManager{mongoCli: cli, instanceID: instanceID, httpCli: httpCli, conf: conf, limits: make(map[string]*rateWindow), sinks: make(map[string]chan *alertDelivery)}
+}
+
+//Reload replace alert configuration including rules defined in config file
+func (m *AlertManager) Reload(conf *AlertConfig) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.conf = conf
+}
+
+func (m *AlertManager) config() *AlertConfig {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.conf
+}
+
+//rules returns rules in config file followed by rules in database, rules in config file take precedence
+//over rules with the same name in database
+func (m *AlertManager) rules() []*AlertRule {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	rules := make([]*AlertRule, 0, len(m.conf.Rules)+len(m.dbRules))
+	names := make(map[string]bool)
+	for _, rule := range m.conf.Rules {
+		rules = append(rules, rule)
+		names[rule.Name] = true
+	}
+	for _, rule := range m.dbRules {
+		if !names[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+//LoadRules load alert rules from database, invalid rules are skipped
+func (m *AlertManager) LoadRules(ctx context.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentAlert, Function: "LoadRules"})
+	cur, err := m.mongoCli.Database(MinerTrackerDB).Collection(AlertRuleTab).Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	rules := make([]*AlertRule, 0)
+	for cur.Next(ctx) {
+		rule := new(AlertRule)
+		err := cur.Decode(rule)
+		if err != nil {
+			return err
+		}
+		if err := rule.Check(); err != nil {
+			entry.WithError(err).Warnf("skip invalid alert rule %s", rule.Name)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	m.dbRules = rules
+	m.lock.Unlock()
+	return nil
+}
+
+//Run reload rules from database and evaluate silent rules periodically until ctx is done,
+//it should be called when current instance becomes leader
+func (m *AlertManager) Run(ctx context.Context) {
+	entry := log.WithFields(log.Fields{Component: ComponentAlert, Function: "Run"})
+	m.lock.Lock()
+	m.lastCheck = time.Now().Unix()
+	m.lock.Unlock()
+	for {
+		err := m.LoadRules(ctx)
+		if err != nil {
+			entry.WithError(err).Error("loading alert rules")
+		}
+		m.checkSilent(ctx)
+		if !sleepContext(ctx, time.Duration(m.config().CheckInterval)*time.Second) {
+			return
+		}
+	}
+}
+
+//checkSilent fire silent rules for miners whose last report passed the threshold since last check,
+//so that a miner is alerted once when it becomes silent
+func (m *AlertManager) checkSilent(ctx context.Context) {
+	entry := log.WithFields(log.Fields{Component: ComponentAlert, Function: "checkSilent"})
+	now := time.Now().Unix()
+	m.lock.Lock()
+	lastCheck := m.lastCheck
+	m.lastCheck = now
+	m.lock.Unlock()
+	collection := m.mongoCli.Database(MinerTrackerDB).Collection(NodeTab)
+	for _, rule := range m.rules() {
+		if rule.Type != AlertSilent {
+			continue
+		}
+		threshold := int64(rule.Minutes) * 60
+		cur, err := collection.Find(ctx, bson.M{"timestamp": bson.M{"$gte": lastCheck - threshold, "$lt": now - threshold}}, options.Find().SetProjection(bson.M{"_id": 1, "poolID": 1, "timestamp": 1}))
+		if err != nil {
+			entry.WithError(err).Errorf("finding silent miners of rule %s", rule.Name)
+			continue
+		}
+		for cur.Next(ctx) {
+			node := new(Node)
+			err := cur.Decode(node)
+			if err != nil {
+				entry.WithError(err).Errorf("decoding silent miner of rule %s", rule.Name)
+				break
+			}
+			m.fire(rule, &Alert{MinerID: node.ID, PoolID: node.PoolID, Field: "timestamp", Old: node.Timestamp})
+		}
+		cur.Close(ctx)
+	}
+}
+
+//Evaluate fire change and increase rules matching the update of miner
+func (m *AlertManager) Evaluate(oldNode, newNode *Node) {
+	for _, rule := range m.rules() {
+		if rule.Type != AlertChange && rule.Type != AlertIncrease {
+			continue
+		}
+		oldValue, ok := nodeField(oldNode, rule.Field)
+		if !ok {
+			continue
+		}
+		newValue, _ := nodeField(newNode, rule.Field)
+		if rule.Type == AlertChange {
+			oldStr, newStr := fmt.Sprint(oldValue.Interface()), fmt.Sprint(newValue.Interface())
+			if oldStr == newStr || (rule.From != "" && oldStr != rule.From) || (rule.To != "" && newStr != rule.To) {
+				continue
+			}
+		} else {
+			oldNum, _ := numeric(oldValue)
+			newNum, _ := numeric(newValue)
+			if newNum <= oldNum {
+				continue
+			}
+		}
+		m.fire(rule, &Alert{MinerID: newNode.ID, PoolID: newNode.PoolID, Field: rule.Field, Old: oldValue.Interface(), New: newValue.Interface()})
+	}
+}
+
+//MinerRegistered fire register rules
+func (m *AlertManager) MinerRegistered(minerID int32) {
+	m.fireAll(AlertRegister, &Alert{MinerID: minerID})
+}
+
+//MinerDeleted fire delete rules
+func (m *AlertManager) MinerDeleted(minerID int32) {
+	m.fireAll(AlertDelete, &Alert{MinerID: minerID})
+}
+
+func (m *AlertManager) fireAll(ruleType string, alert *Alert) {
+	for _, rule := range m.rules() {
+		if rule.Type == ruleType {
+			a := *alert
+			m.fire(rule, &a)
+		}
+	}
+}
+
+//allow check rate limit of rule
+func (m *AlertManager) allow(rule *AlertRule) bool {
+	if rule.RateLimit <= 0 {
+		return true
+	}
+	minute := time.Now().Unix() / 60
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	w, ok := m.limits[rule.Name]
+	if !ok || w.minute != minute {
+		w = &rateWindow{minute: minute}
+		m.limits[rule.Name] = w
+	}
+	if w.count >= rule.RateLimit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+//fire put alert of rule into delivery queue
+func (m *AlertManager) fire(rule *AlertRule, alert *Alert) {
+	entry := log.WithFields(log.Fields{Component: ComponentAlert, Function: "fire", MinerID: alert.MinerID})
+	conf := m.config()
+	webhook := rule.Webhook
+	if webhook == "" {
+		webhook = conf.WebhookURL
+	}
+	if webhook == "" {
+		entry.Debugf("no webhook for alert rule %s", rule.Name)
+		return
+	}
+	if !m.allow(rule) {
+		entry.Warnf("alert of rule %s dropped by rate limit", rule.Name)
+		return
+	}
+	alert.Rule = rule.Name
+	alert.Type = rule.Type
+	alert.Timestamp = time.Now().Unix()
+	alert.Instance = m.instanceID
+	body, err := json.Marshal(alert)
+	if err != nil {
+		entry.WithError(err).Errorf("encoding alert of rule %s", rule.Name)
+		return
+	}
+	d := &alertDelivery{url: webhook, body: body}
+	if conf.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(conf.Secret))
+		mac.Write(body)
+		d.sign = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	if m.enqueue(d) {
+		entry.Infof("alert of rule %s fired", rule.Name)
+	} else {
+		entry.Warnf("alert queue of %s is full, alert of rule %s dropped", webhook, rule.Name)
+	}
+}
+
+//enqueue put alert into queue of its webhook, delivery worker of webhook is started if not running
+func (m *AlertManager) enqueue(d *alertDelivery) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	queue, ok := m.sinks[d.url]
+	if !ok {
+		queue = make(chan *alertDelivery, alertQueueSize)
+		m.sinks[d.url] = queue
+		go m.deliver(d.url, queue)
+	}
+	select {
+	case queue <- d:
+		return true
+	default:
+		return false
+	}
+}
+
+//deliver send alerts in queue to webhook in order, each alert is retried for specified times, worker exits when
+//no alert is fired to webhook for a while
+func (m *AlertManager) deliver(url string, queue chan *alertDelivery) {
+	entry := log.WithFields(log.Fields{Component: ComponentAlert, Function: "deliver"})
+	for {
+		select {
+		case d := <-queue:
+			conf := m.config()
+			for i := 0; ; i++ {
+				err := m.post(d)
+				if err == nil {
+					break
+				}
+				if i >= conf.Retries {
+					entry.WithError(err).Errorf("delivering alert to %s failed: %s", d.url, string(d.body))
+					break
+				}
+				entry.WithError(err).Warnf("delivering alert to %s, retry later", d.url)
+				time.Sleep(time.Duration(conf.RetryInterval) * time.Second)
+			}
+		case <-time.After(time.Duration(alertWorkerIdle) * time.Second):
+			//alerts are enqueued with lock held, so no alert is left in queue after worker is removed
+			m.lock.Lock()
+			if len(queue) == 0 {
+				delete(m.sinks, url)
+				m.lock.Unlock()
+				return
+			}
+			m.lock.Unlock()
+		}
+	}
+}
+
+func (m *AlertManager) post(d *alertDelivery) error {
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.sign != "" {
+		req.Header.Set(SignatureHeader, d.sign)
+	}
+	resp, err := m.httpCli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returns status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aurawing/auramq/msg"
+	"github.com/aurawing/eos-go"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	ytcrypto "github.com/yottachain/YTCrypto"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *Service) auth(cred *msg.AuthReq) bool {
+	entry := log.WithFields(log.Fields{Component: ComponentAuth, Function: "auth"})
+	signMsg := new(pb.SignMessage)
+	err := proto.Unmarshal(cred.Credential, signMsg)
+	if err != nil {
+		entry.WithError(err).Error("decoding SignMessage")
+		return false
+	}
+	entry = entry.WithFields(log.Fields{AccountName: signMsg.AccountName, ClientID: cred.Id})
+	auth, err := s.authCache.Get(context.Background(), signMsg.AccountName)
+	if err != nil {
+		entry.WithError(err).Errorf("decoding Auth record ofr account: %s", signMsg.AccountName)
+		return false
+	}
+	if auth == nil {
+		entry.Debugf("no Auth record of account: %s", signMsg.AccountName)
+		return false
+	}
+	if auth.Verify(signMsg.Data, signMsg.Signature) {
+		now := time.Now().Unix()
+		if s.authCache.shouldRecordLogin(auth, now) {
+			//other instances may have recorded a newer login in this period, skip writing in that case
+			collection := s.mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+			_, err := collection.UpdateOne(context.Background(), bson.M{"_id": auth.Account, "lastLogin": bson.M{"$not": bson.M{"$gt": now - loginRecordInterval}}}, bson.M{"$set": bson.M{"lastLogin": now, "lastClientID": cred.Id}})
+			if err != nil {
+				entry.WithError(err).Warn("recording last login failed")
+			}
+		}
+		entry.Debug("client authenticated")
+		return true
+	}
+	entry.Debug("verifying signature failed")
+	return false
+}
+
+//Verify check if signature is signed by any key of permission whose weight meets threshold of the permission
+func (auth *Auth) Verify(data []byte, signature string) bool {
+	if len(auth.Keys) == 0 {
+		return auth.PublicKey != "" && ytcrypto.Verify(auth.PublicKey, data, signature)
+	}
+	for _, key := range auth.Keys {
+		if key.Weight >= auth.Threshold && ytcrypto.Verify(key.PublicKey, data, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+//AddAuth add an account for authenticating MQ clients, public keys of the permission are fetched from BP immediately,
+//active permission is used if permission is empty and the account does not exist
+func AddAuth(ctx context.Context, api *eos.API, mongoCli *mongo.Client, account string, permission string) (*Auth, error) {
+	entry := log.WithFields(log.Fields{Component: ComponentAuth, Function: "AddAuth", AccountName: account})
+	collection := mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+	if permission == "" {
+		old := new(Auth)
+		err := collection.FindOne(ctx, bson.M{"_id": account}).Decode(old)
+		if err != nil && err != mongo.ErrNoDocuments {
+			entry.WithError(err).Error("reading auth record failed")
+			return nil, err
+		}
+		permission = old.permission()
+	}
+	threshold, keys, err := fetchAuthKeys(api, account, permission)
+	if err != nil {
+		entry.WithError(err).Errorf("fetching public keys of permission %s failed", permission)
+		return nil, err
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	auth := new(Auth)
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": account}, bson.M{"$set": authKeysUpdate(permission, threshold, keys)}, opts).Decode(auth)
+	if err != nil {
+		entry.WithError(err).Error("adding auth record failed")
+		return nil, err
+	}
+	entry.Info("auth record added")
+	return auth, nil
+}
+
+//RemoveAuth remove an account for authenticating MQ clients
+func RemoveAuth(ctx context.Context, mongoCli *mongo.Client, account string) error {
+	entry := log.WithFields(log.Fields{Component: ComponentAuth, Function: "RemoveAuth", AccountName: account})
+	collection := mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": account})
+	if err != nil {
+		entry.WithError(err).Error("removing auth record failed")
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("no such account: %s", account)
+	}
+	entry.Info("auth record removed")
+	return nil
+}
+
+//ListAuth list all accounts for authenticating MQ clients
+func ListAuth(ctx context.Context, mongoCli *mongo.Client) ([]*Auth, error) {
+	collection := mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+	cur, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	auths := make([]*Auth, 0)
+	for cur.Next(ctx) {
+		auth := new(Auth)
+		err := cur.Decode(auth)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, auth)
+	}
+	return auths, nil
+}
+
+//RefreshAuth refresh public key of one account from BP, or all accounts concurrently by workers if account is empty
+func RefreshAuth(ctx context.Context, api *eos.API, mongoCli *mongo.Client, account string, workers int) error {
+	if account == "" {
+		return refreshAuth(api, mongoCli, workers)
+	}
+	collection := mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": account})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no such account: %s", account)
+	}
+	_, err = AddAuth(ctx, api, mongoCli, account, "")
+	return err
+}
+
+func refreshAuth(api *eos.API, mongoCli *mongo.Client, workers int) error {
+	entry := log.WithFields(log.Fields{Component: ComponentAuth, Function: "refreshAuth"})
+	collection := mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+	auths, err := ListAuth(context.Background(), mongoCli)
+	if err != nil {
+		entry.WithError(err).Error("traversaling auth record failed")
+		return err
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	ch := make(chan *Auth)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for auth := range ch {
+				threshold, keys, err := fetchAuthKeys(api, auth.Account, auth.permission())
+				if err != nil {
+					entry.WithField(AccountName, auth.Account).WithError(err).Error("fetching public keys failed")
+					continue
+				}
+				if threshold != auth.Threshold || !EqualSorted(keys, auth.Keys) {
+					_, err := collection.UpdateOne(context.Background(), bson.M{"_id": auth.Account}, bson.M{"$set": authKeysUpdate(auth.permission(), threshold, keys)})
+					if err != nil {
+						entry.WithField(AccountName, auth.Account).WithError(err).Error("update public keys failed")
+					}
+				}
+			}
+		}()
+	}
+	for _, auth := range auths {
+		ch <- auth
+	}
+	close(ch)
+	wg.Wait()
+	entry.Info("refreshed Auth table")
+	return nil
+}
+
+func (auth *Auth) permission() string {
+	if auth.Permission == "" {
+		return DefaultPermission
+	}
+	return auth.Permission
+}
+
+func authKeysUpdate(permission string, threshold uint32, keys []*AuthKey) bson.M {
+	return bson.M{"permission": permission, "threshold": threshold, "keys": keys, "publickey": keys[0].PublicKey}
+}
+
+//fetchAuthKeys fetch threshold and all public keys without prefix of permission of account from BP
+func fetchAuthKeys(api *eos.API, accountName string, perm string) (uint32, []*AuthKey, error) {
+	accountResp, err := api.GetAccount(eos.AN(accountName))
+	if err != nil {
+		return 0, nil, fmt.Errorf("get account info: %s", err)
+	}
+	for _, p := range accountResp.Permissions {
+		if p.PermName != perm {
+			continue
+		}
+		if len(p.RequiredAuth.Keys) == 0 {
+			return 0, nil, fmt.Errorf("no key found in permission %s", perm)
+		}
+		keys := make([]*AuthKey, 0, len(p.RequiredAuth.Keys))
+		for _, k := range p.RequiredAuth.Keys {
+			pubkey := k.PublicKey.String()
+			if strings.HasPrefix(pubkey, "YTA") || strings.HasPrefix(pubkey, "EOS") {
+				pubkey = string(pubkey[3:])
+			}
+			keys = append(keys, &AuthKey{PublicKey: pubkey, Weight: uint32(k.Weight)})
+		}
+		return p.RequiredAuth.Threshold, keys, nil
+	}
+	return 0, nil, fmt.Errorf("no permission found: %s", perm)
+}
@@ -0,0 +1,200 @@
+package yttracker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//expired missing accounts are purged when count of missing accounts reaches this value, and no more missing
+//accounts are cached if all of them are unexpired, so that memory is bounded under floods of unique accounts
+const maxMissingAccounts = 10000
+
+//login of an account is recorded at most once in this period(second), so that frequent reconnections of MQ
+//clients will not cause a database write each time
+const loginRecordInterval = 600
+
+//loginFields fields of auth record updated on each successful login, which do not affect authenticating
+var loginFields = map[string]bool{"lastLogin": true, "lastClientID": true}
+
+//AuthCache in-memory cache of auth records, accounts not found in database are cached as missing for a while
+//so that connection floods with unknown accounts will not hit database
+type AuthCache struct {
+	mongoCli    *mongo.Client
+	negativeTTL int64
+	lock        sync.RWMutex
+	auths       map[string]*Auth
+	missing     map[string]int64
+	logins      map[string]int64
+}
+
+//AuthChangeEvent change event of auth collection
+type AuthChangeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+//loginOnly check whether the event only records login of account, cached record need not be invalidated
+func (event *AuthChangeEvent) loginOnly() bool {
+	if event.OperationType != "update" || len(event.UpdateDescription.RemovedFields) > 0 {
+		return false
+	}
+	for k := range event.UpdateDescription.UpdatedFields {
+		if !loginFields[k] {
+			return false
+		}
+	}
+	return true
+}
+
+//NewAuthCache create a new auth cache, negativeTTL is the time(second) of caching missing accounts
+func NewAuthCache(mongoCli *mongo.Client, negativeTTL int) *AuthCache {
+	return &AuthCache{mongoCli: mongoCli, negativeTTL: int64(negativeTTL), auths: make(map[string]*Auth), missing: make(map[string]int64), logins: make(map[string]int64)}
+}
+
+//Load reload all auth records from database
+func (cache *AuthCache) Load(ctx context.Context) error {
+	auths, err := ListAuth(ctx, cache.mongoCli)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]*Auth)
+	for _, auth := range auths {
+		m[auth.Account] = auth
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.auths = m
+	cache.missing = make(map[string]int64)
+	cache.logins = make(map[string]int64)
+	return nil
+}
+
+//Get find auth record of account in cache, database is queried if not cached, nil is returned if account not exists
+func (cache *AuthCache) Get(ctx context.Context, account string) (*Auth, error) {
+	now := time.Now().Unix()
+	cache.lock.RLock()
+	auth, ok := cache.auths[account]
+	expiration, missing := cache.missing[account]
+	cache.lock.RUnlock()
+	if ok {
+		return auth, nil
+	}
+	if missing && expiration > now {
+		return nil, nil
+	}
+	auth = new(Auth)
+	err := cache.mongoCli.Database(MinerTrackerDB).Collection(AuthTab).FindOne(ctx, bson.M{"_id": account}).Decode(auth)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if err == mongo.ErrNoDocuments {
+		if len(cache.missing) >= maxMissingAccounts {
+			for k, v := range cache.missing {
+				if v <= now {
+					delete(cache.missing, k)
+				}
+			}
+		}
+		if len(cache.missing) >= maxMissingAccounts {
+			log.WithFields(log.Fields{Component: ComponentAuth, Function: "Get", AccountName: account}).Debug("too many missing accounts cached, skip caching")
+			return nil, nil
+		}
+		cache.missing[account] = now + cache.negativeTTL
+		return nil, nil
+	}
+	cache.auths[account] = auth
+	delete(cache.missing, account)
+	return auth, nil
+}
+
+//SetNegativeTTL change the time(second) of caching missing accounts
+func (cache *AuthCache) SetNegativeTTL(negativeTTL int) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.negativeTTL = int64(negativeTTL)
+}
+
+//Invalidate remove account from cache
+func (cache *AuthCache) Invalidate(account string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	delete(cache.auths, account)
+	delete(cache.missing, account)
+	delete(cache.logins, account)
+}
+
+//shouldRecordLogin check whether login of auth at now should be recorded to database, true is returned if
+//last login known by this cache is older than loginRecordInterval, and the login is then regarded as recorded
+func (cache *AuthCache) shouldRecordLogin(auth *Auth, now int64) bool {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	last, ok := cache.logins[auth.Account]
+	if !ok {
+		last = auth.LastLogin
+	}
+	if now-last < loginRecordInterval {
+		return false
+	}
+	cache.logins[auth.Account] = now
+	return true
+}
+
+//Watch invalidate cached accounts by change stream of auth collection, returns false immediately
+//if change stream is not supported by database, e.g. mongoDB is not running as replica set
+func (cache *AuthCache) Watch(ctx context.Context) bool {
+	entry := log.WithFields(log.Fields{Component: ComponentAuth, Function: "Watch"})
+	collection := cache.mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
+	stream, err := collection.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		entry.WithError(err).Warn("change stream of auth collection is not supported, cache is only reloaded periodically")
+		return false
+	}
+	go func() {
+		for {
+			for stream.Next(ctx) {
+				event := new(AuthChangeEvent)
+				err := stream.Decode(event)
+				if err != nil {
+					entry.WithError(err).Error("decoding change event of auth collection")
+					continue
+				}
+				if event.loginOnly() {
+					continue
+				}
+				entry.WithField(AccountName, event.DocumentKey.ID).Debugf("auth record changed: %s", event.OperationType)
+				cache.Invalidate(event.DocumentKey.ID)
+			}
+			entry.WithError(stream.Err()).Warn("change stream of auth collection closed")
+			stream.Close(context.Background())
+			if ctx.Err() != nil {
+				return
+			}
+			for {
+				time.Sleep(time.Duration(3) * time.Second)
+				stream, err = collection.Watch(ctx, mongo.Pipeline{})
+				if err == nil {
+					break
+				}
+				entry.WithError(err).Error("re-opening change stream of auth collection")
+			}
+			err := cache.Load(ctx)
+			if err != nil {
+				entry.WithError(err).Error("reloading auth cache")
+			}
+		}
+	}()
+	return true
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aurawing/eos-go"
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+)
+
+var authPermission string
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "manage accounts for authenticating MQ clients",
+}
+
+var authAddCmd = &cobra.Command{
+	Use:   "add <account>",
+	Short: "add an account or change its permission, public keys are fetched from BP immediately",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		auth, err := yttracker.AddAuth(context.Background(), eos.New(config.EOSURL), mongoCli, args[0], authPermission)
+		if err != nil {
+			fmt.Printf("adding account %s failed: %s\n", args[0], err)
+			os.Exit(1)
+		}
+		fmt.Printf("account %s added, permission: %s, threshold: %d\n", auth.Account, auth.Permission, auth.Threshold)
+		for _, key := range auth.Keys {
+			fmt.Printf("\t%s\tweight: %d\n", key.PublicKey, key.Weight)
+		}
+	},
+}
+
+var authRemoveCmd = &cobra.Command{
+	Use:   "remove <account>",
+	Short: "remove an account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		err := yttracker.RemoveAuth(context.Background(), mongoCli, args[0])
+		if err != nil {
+			fmt.Printf("removing account %s failed: %s\n", args[0], err)
+			os.Exit(1)
+		}
+		fmt.Printf("account %s removed\n", args[0])
+	},
+}
+
+var authListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list all accounts with last successful MQ login",
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		auths, err := yttracker.ListAuth(context.Background(), mongoCli)
+		if err != nil {
+			fmt.Printf("listing accounts failed: %s\n", err)
+			os.Exit(1)
+		}
+		for _, auth := range auths {
+			lastLogin := "never"
+			if auth.LastLogin != 0 {
+				lastLogin = fmt.Sprintf("%s(%s)", time.Unix(auth.LastLogin, 0).Format(time.RFC3339), auth.LastClientID)
+			}
+			keys := make([]string, 0, len(auth.Keys))
+			for _, key := range auth.Keys {
+				keys = append(keys, fmt.Sprintf("%s:%d", key.PublicKey, key.Weight))
+			}
+			fmt.Printf("%s\t%s\tthreshold: %d\t%s\t%s\n", auth.Account, auth.Permission, auth.Threshold, strings.Join(keys, ","), lastLogin)
+		}
+	},
+}
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh [account]",
+	Short: "refresh public key of an account, or all accounts if no account specified",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		account := ""
+		if len(args) > 0 {
+			account = args[0]
+		}
+		err := yttracker.RefreshAuth(context.Background(), eos.New(config.EOSURL), mongoCli, account, config.Misc.RefreshAuthWorkers)
+		if err != nil {
+			fmt.Printf("refreshing public key failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("public key refreshed")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authAddCmd)
+	authCmd.AddCommand(authRemoveCmd)
+	authCmd.AddCommand(authListCmd)
+	authCmd.AddCommand(authRefreshCmd)
+
+	authAddCmd.Flags().StringVar(&authPermission, "permission", "", "permission of account used for authenticating, default is active or the permission already set")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "configuration utilities",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "check configuration and print all problems found",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := unmarshalConfig()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		//secret files failed to read are reported together with other problems
+		secretErr := config.LoadSecretFiles()
+		if !checkConfig(config, secretErr) {
+			os.Exit(1)
+		}
+		fmt.Println("configuration is valid")
+	},
+}
+
+//checkConfig validate configuration and print all problems found, including problems of loading secret files if any
+func checkConfig(config *yttracker.Config, secretErr error) bool {
+	problems := make([]string, 0)
+	for _, err := range []error{secretErr, config.Validate()} {
+		if err == nil {
+			continue
+		}
+		if cerr, ok := err.(*yttracker.ConfigError); ok {
+			problems = append(problems, cerr.Problems...)
+		} else {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) == 0 {
+		return true
+	}
+	fmt.Printf("%d problems found in configuration:\n", len(problems))
+	for _, p := range problems {
+		fmt.Printf("  - %s\n", p)
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configCheckCmd)
+}
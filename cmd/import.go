@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	importSourceURL        string
+	importSourceDB         string
+	importSourceCollection string
+	importFieldMap         []string
+	importBatchSize        int64
+	importTask             string
+	importRestart          bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import miner information from SN database",
+	Long:  `import miner information from node collection of SN database into node collection of tracker, interrupted importing can be resumed by running this command again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		fieldMap, err := parseFieldMap(importFieldMap)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		srcCli := connectMongoURL(importSourceURL)
+		defer srcCli.Disconnect(context.Background())
+		task := importTask
+		if task == "" {
+			task = fmt.Sprintf("%s.%s", importSourceDB, importSourceCollection)
+		}
+		count, err := yttracker.ImportNodes(context.Background(), mongoCli, &yttracker.NodeImport{
+			Task:          task,
+			SrcCli:        srcCli,
+			SrcDB:         importSourceDB,
+			SrcCollection: importSourceCollection,
+			FieldMap:      fieldMap,
+			BatchSize:     importBatchSize,
+			Restart:       importRestart,
+			Progress: func(imported, total int64) {
+				fmt.Printf("imported %d/%d nodes\n", imported, total)
+			},
+		})
+		if err != nil {
+			fmt.Printf("importing nodes failed after %d nodes imported: %s\n", count, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d nodes imported\n", count)
+	},
+}
+
+func parseFieldMap(pairs []string) (map[string]string, error) {
+	fieldMap := make(map[string]string)
+	for _, pair := range pairs {
+		idx := strings.Index(pair, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("field mapping must be in the form of source=destination: %s", pair)
+		}
+		fieldMap[pair[0:idx]] = pair[idx+1:]
+	}
+	return fieldMap, nil
+}
+
+func connectMongoURL(url string) *mongo.Client {
+	mongoCli, err := mongo.Connect(context.Background(), options.Client().ApplyURI(url))
+	if err != nil {
+		fmt.Printf("creating mongo DB client failed: %s\n", err)
+		os.Exit(1)
+	}
+	return mongoCli
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVar(&importSourceURL, "source-url", "mongodb://127.0.0.1:27017/?connect=direct", "URL of SN mongoDB")
+	importCmd.Flags().StringVar(&importSourceDB, "source-db", "yotta", "database name of node collection in SN")
+	importCmd.Flags().StringVar(&importSourceCollection, "source-collection", "Node", "name of node collection in SN")
+	importCmd.Flags().StringSliceVar(&importFieldMap, "map", []string{}, "field mapping from SN to tracker, in the form of --map \"src1=dst1,src2=dst2\", field mapped to \"-\" will be dropped")
+	importCmd.Flags().Int64Var(&importBatchSize, "batch-size", 1000, "count of nodes imported between two checkpoints")
+	importCmd.Flags().StringVar(&importTask, "task", "", "name of importing task for saving checkpoint, default is <source-db>.<source-collection>")
+	importCmd.Flags().BoolVar(&importRestart, "restart", false, "ignore checkpoint and import from the first node")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "manage indexes of tracker collections",
+	Long:  `list or create indexes declared by tracker and specified in indexes section of config file.`,
+}
+
+var indexListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list existing and expected indexes of tracker collections",
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		indexes, err := yttracker.ListIndexes(context.Background(), mongoCli, config.Indexes)
+		if err != nil {
+			fmt.Printf("listing indexes failed: %s\n", err)
+			os.Exit(1)
+		}
+		for _, index := range indexes {
+			fmt.Printf("%s\t%s\texpected: %s\texisting: %s\t%s\n", index.Collection, index.Name, index.Expected, index.Existing, index.Status)
+		}
+	},
+}
+
+var indexEnsureCmd = &cobra.Command{
+	Use:   "ensure",
+	Short: "create missing indexes of tracker collections",
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		err := yttracker.CreateUpdateLog(context.Background(), mongoCli, config.AuraMQ.Replay)
+		if err != nil {
+			fmt.Printf("creating update log failed: %s\n", err)
+			os.Exit(1)
+		}
+		err = yttracker.EnsureIndexes(context.Background(), mongoCli, config.Indexes)
+		if err != nil {
+			fmt.Printf("ensuring indexes failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("indexes ensured")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexListCmd)
+	indexCmd.AddCommand(indexEnsureCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+)
+
+var migrateTo int
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "apply pending schema migrations of tracker collections",
+	Long:  `apply pending schema migrations of tracker collections in order, interrupted migration continues from the failed step when running this command again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		target := migrateTo
+		if target <= 0 {
+			target = yttracker.LatestSchemaVersion()
+		}
+		applied, err := yttracker.Migrate(context.Background(), mongoCli, target)
+		for _, m := range applied {
+			fmt.Printf("migrated to version %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			fmt.Printf("migrating schema failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d migrations applied\n", len(applied))
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show current schema version and pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		version, err := yttracker.GetSchemaVersion(context.Background(), mongoCli)
+		if err != nil {
+			fmt.Printf("reading schema version failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("schema version: %d, latest: %d\n", version, yttracker.LatestSchemaVersion())
+		pending, err := yttracker.PendingMigrations(context.Background(), mongoCli)
+		if err != nil {
+			fmt.Printf("reading pending migrations failed: %s\n", err)
+			os.Exit(1)
+		}
+		for _, m := range pending {
+			fmt.Printf("pending\t%d\t%s\n", m.Version, m.Description)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+
+	migrateCmd.Flags().IntVar(&migrateTo, "to", 0, "schema version to migrate to, 0 for the latest version")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+)
+
+//watchConfig reload configuration when config file changes or SIGHUP is received
+func watchConfig(tracker *yttracker.MinerTracker, config *yttracker.Config) {
+	var lock sync.Mutex
+	current := config
+	reload := func(reason string, reread bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		entry := log.WithFields(log.Fields{yttracker.Function: "reloadConfig"})
+		entry.Infof("reloading configuration: %s", reason)
+		if reread {
+			if err := viper.ReadInConfig(); err != nil {
+				if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+					entry.WithError(err).Error("reading config file failed, configuration not reloaded")
+					return
+				}
+			}
+		}
+		newConfig, err := decodeConfig()
+		if err != nil {
+			entry.WithError(err).Error("configuration not reloaded")
+			return
+		}
+		if err := newConfig.Validate(); err != nil {
+			entry.WithError(err).Error("configuration not reloaded")
+			return
+		}
+		changes := yttracker.DiffConfig(current, newConfig)
+		if len(changes) == 0 {
+			entry.Info("configuration not changed")
+			return
+		}
+		for _, change := range changes {
+			if change.Reloadable {
+				entry.Infof("configuration changed: %s", change)
+			} else {
+				entry.Warnf("configuration changed: %s, restart is required to take effect", change)
+			}
+		}
+		setLogHook(newConfig.Logger)
+		tracker.Reload(newConfig)
+		current = newConfig
+	}
+	if viper.ConfigFileUsed() != "" {
+		viper.OnConfigChange(func(e fsnotify.Event) {
+			reload("config file changed", false)
+		})
+		viper.WatchConfig()
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reload("SIGHUP received", true)
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	replaySN     int
+	replayStart  int64
+	replayFrom   int64
+	replayTo     int64
+	replayDryRun bool
+	replayForce  bool
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "replay miner logs or adjust tracking progress",
+	Long:  `replay miner logs of SN from a given point, or reset/set the start cursor of tracking progress.`,
+}
+
+var replaySetStartCmd = &cobra.Command{
+	Use:   "set-start",
+	Short: "set start cursor of tracking progress",
+	Run: func(cmd *cobra.Command, args []string) {
+		setStart(replayStart)
+	},
+}
+
+var replayResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "reset start cursor of tracking progress to 0",
+	Run: func(cmd *cobra.Command, args []string) {
+		setStart(0)
+	},
+}
+
+var replayRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "replay miner logs in range [from, to)",
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		mongoCli := connectMongo(config)
+		defer mongoCli.Disconnect(context.Background())
+		total := 0
+		for _, ep := range selectSN(config) {
+			changes, err := yttracker.ReplayMinerLogs(context.Background(), mongoCli, &http.Client{}, ep.URL, replayFrom, replayTo, config.MinerStat.BatchSize, replayDryRun, replayForce)
+			for _, c := range changes {
+				fmt.Printf("SN%d\tlog %d\tminer %d\t%s\t%s\n", ep.ID, c.LogID, c.MinerID, c.Type, c.Action)
+			}
+			total += len(changes)
+			if err != nil {
+				fmt.Printf("replaying miner logs of SN%d failed: %s\n", ep.ID, err)
+				os.Exit(1)
+			}
+		}
+		if replayDryRun {
+			fmt.Printf("%d miner logs would be replayed (dry run)\n", total)
+		} else {
+			fmt.Printf("%d miner logs replayed\n", total)
+		}
+	},
+}
+
+func setStart(start int64) {
+	config := loadConfig()
+	initLog(config)
+	mongoCli := connectMongo(config)
+	defer mongoCli.Disconnect(context.Background())
+	for _, ep := range selectSN(config) {
+		old, err := yttracker.SetTrackProgress(context.Background(), mongoCli, ep, start)
+		if err != nil {
+			fmt.Printf("set start cursor of SN%d failed: %s\n", ep.ID, err)
+			os.Exit(1)
+		}
+		fmt.Printf("SN%d\t%s\tstart: %d -> %d\n", ep.ID, ep.URL, old, start)
+	}
+}
+
+func selectSN(config *yttracker.Config) []*yttracker.SNEndpoint {
+	if replaySN < 0 {
+		return config.MinerStat.AllSyncURLs
+	}
+	for _, ep := range config.MinerStat.AllSyncURLs {
+		if ep.ID == int32(replaySN) {
+			return []*yttracker.SNEndpoint{ep}
+		}
+	}
+	fmt.Printf("no sync URL is configured for SN%d\n", replaySN)
+	os.Exit(1)
+	return nil
+}
+
+func connectMongo(config *yttracker.Config) *mongo.Client {
+	return connectMongoURL(config.MongoDBURL)
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+	replayCmd.AddCommand(replaySetStartCmd)
+	replayCmd.AddCommand(replayResetCmd)
+	replayCmd.AddCommand(replayRunCmd)
+
+	replayCmd.PersistentFlags().IntVar(&replaySN, "sn", -1, "SN ID of sync URL in miner-stat.all-sync-urls, -1 for all")
+	replaySetStartCmd.Flags().Int64Var(&replayStart, "start", 0, "new start cursor of tracking progress")
+	replaySetStartCmd.MarkFlagRequired("start")
+	replayRunCmd.Flags().Int64Var(&replayFrom, "from", 0, "ID of the first miner log to be replayed")
+	replayRunCmd.Flags().Int64Var(&replayTo, "to", 0, "miner logs with ID not less than this value will not be replayed, 0 for no limit")
+	replayRunCmd.Flags().BoolVar(&replayDryRun, "dry-run", false, "only print changes without modifying database")
+	replayRunCmd.Flags().BoolVar(&replayForce, "force", false, "also apply miner logs not newer than lastLogID of miner, which may delete miners registered again later")
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	homedir "github.com/mitchellh/go-homedir"
+	"github.com/mitchellh/mapstructure"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	yttracker "github.com/yottachain/yotta-miner-tracker"
@@ -26,46 +29,100 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
-		config := new(yttracker.Config)
-		if err := viper.Unmarshal(config); err != nil {
-			panic(fmt.Sprintf("unable to decode into config struct, %v\n", err))
+		config := loadConfig()
+		if !checkConfig(config, nil) {
+			fmt.Println("refusing to start with invalid configuration")
+			os.Exit(1)
 		}
 		initLog(config)
-		tracker, err := yttracker.New(config.MongoDBURL, config.EOSURL, config.AuraMQ, config.MinerStat, config.Misc)
+		tracker, err := yttracker.New(config.MongoDBURL, config.EOSURL, config.AuraMQ, config.MinerStat, config.Alert, config.Offline, config.Reconcile, config.Indexes, config.Misc)
 		if err != nil {
 			panic(fmt.Sprintf("fatal error when starting service: %s\n", err))
 		}
-		tracker.TrackingStat(context.Background())
+		tracker.RunElection(context.Background())
+		watchConfig(tracker, config)
 		tracker.Start(config.HTTPBindAddr)
 	},
 }
 
+func loadConfig() *yttracker.Config {
+	config, err := decodeConfig()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return config
+}
+
+func decodeConfig() (*yttracker.Config, error) {
+	config, err := unmarshalConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.LoadSecretFiles(); err != nil {
+		return nil, fmt.Errorf("loading secret files failed: %s", err)
+	}
+	return config, nil
+}
+
+//unmarshalConfig decode configuration without reading secret files
+func unmarshalConfig() (*yttracker.Config, error) {
+	config := new(yttracker.Config)
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		yttracker.StringToSNEndpointHookFunc(),
+		yttracker.StringToStringMapHookFunc(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err := viper.Unmarshal(config, hook); err != nil {
+		return nil, fmt.Errorf("unable to decode into config struct, %v", err)
+	}
+	return config, nil
+}
+
 func initLog(config *yttracker.Config) {
-	switch strings.ToLower(config.Logger.Output) {
-	case "file":
-		writer, _ := rotatelogs.New(
-			config.Logger.FilePath+".%Y%m%d",
-			rotatelogs.WithLinkName(config.Logger.FilePath),
-			rotatelogs.WithMaxAge(time.Duration(config.Logger.MaxAge)*time.Hour),
-			rotatelogs.WithRotationTime(time.Duration(config.Logger.RotationTime)*time.Hour),
-		)
-		log.SetOutput(writer)
-	case "stdout":
-		log.SetOutput(os.Stdout)
-	default:
-		fmt.Printf("no such option: %s, use stdout\n", config.Logger.Output)
-		log.SetOutput(os.Stdout)
+	writers := make([]io.Writer, 0)
+	for _, output := range config.Logger.Outputs() {
+		switch output {
+		case "file":
+			writer, _ := rotatelogs.New(
+				config.Logger.FilePath+".%Y%m%d",
+				rotatelogs.WithLinkName(config.Logger.FilePath),
+				rotatelogs.WithMaxAge(time.Duration(config.Logger.MaxAge)*time.Hour),
+				rotatelogs.WithRotationTime(time.Duration(config.Logger.RotationTime)*time.Hour),
+			)
+			writers = append(writers, writer)
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		default:
+			fmt.Printf("no such option: %s, ignored\n", output)
+		}
+	}
+	if len(writers) == 0 {
+		fmt.Println("no valid log output, use stdout")
+		writers = append(writers, os.Stdout)
 	}
-	log.SetFormatter(&log.TextFormatter{})
-	levelMap := make(map[string]log.Level)
-	levelMap["panic"] = log.PanicLevel
-	levelMap["fatal"] = log.FatalLevel
-	levelMap["error"] = log.ErrorLevel
-	levelMap["warn"] = log.WarnLevel
-	levelMap["info"] = log.InfoLevel
-	levelMap["debug"] = log.DebugLevel
-	levelMap["trace"] = log.TraceLevel
-	log.SetLevel(levelMap[strings.ToLower(config.Logger.Level)])
+	//all entries are written by the hook set in setLogHook
+	logWriter = io.MultiWriter(writers...)
+	log.SetOutput(ioutil.Discard)
+	log.SetFormatter(yttracker.DiscardFormatter{})
+	setLogHook(config.Logger)
+}
+
+//logWriter writer of all log outputs
+var logWriter io.Writer
+
+//setLogHook set format and levels of logger, which can be changed when reloading configuration
+func setLogHook(conf *yttracker.LogConfig) {
+	hook, err := conf.NewHook(logWriter)
+	if err != nil {
+		fmt.Printf("invalid log format or level: %s, use text format and info level\n", err)
+		hook = &yttracker.ComponentHook{Formatter: &log.TextFormatter{}, Writer: logWriter, Default: log.InfoLevel}
+	}
+	hooks := make(log.LevelHooks)
+	hooks.Add(hook)
+	log.StandardLogger().ReplaceHooks(hooks)
+	log.SetLevel(hook.MaxLevel())
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -112,9 +169,10 @@ func initConfig() {
 		viper.SetConfigType("yaml")
 	}
 
-	// viper.AutomaticEnv() // read in environment variables that match
-	// viper.SetEnvPrefix("analysis")
-	// viper.SetEnvKeyReplacer(strings.NewReplacer("_", "."))
+	// read in environment variables that match, e.g. YTTRACKER_AURAMQ_CLIENT_PRIVATE_KEY for auramq.client.private-key
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -131,13 +189,18 @@ func initConfig() {
 	}
 }
 
+//EnvPrefix prefix of environment variables overriding config
+const EnvPrefix = "YTTRACKER"
+
 var (
 	//DefaultHTTPBindAddr default binding address of HTTP server
 	DefaultHTTPBindAddr string = ":8080"
 	//DefaultEOSURL default address of EOS server
-	DefaultEOSURL string = "127.0.0.1:8888"
+	DefaultEOSURL string = "http://127.0.0.1:8888"
 	//DefaultMongoDBURL default value of mongo DB URL
 	DefaultMongoDBURL string = "mongodb://127.0.0.1:27017/?connect=direct"
+	//DefaultMongoDBURLFile default value of file containing mongo DB URL
+	DefaultMongoDBURLFile string = ""
 
 	//DefaultAuramqServerBindAddr default binding address of AuraMQ server
 	DefaultAuramqServerBindAddr string = ":8787"
@@ -173,6 +236,32 @@ var (
 	DefaultAuramqClientAccount = ""
 	//DefaultAuramqClientPrivateKey default value of private key for authenticating
 	DefaultAuramqClientPrivateKey = ""
+	//DefaultAuramqClientPrivateKeyFile default value of file containing private key for authenticating
+	DefaultAuramqClientPrivateKeyFile = ""
+	//DefaultAuramqFederationPeers default value of MQ URLs of peers
+	DefaultAuramqFederationPeers = []string{}
+	//DefaultAuramqFederationTopic default value of topic for relaying miner information between peers
+	DefaultAuramqFederationTopic = "federation"
+	//DefaultAuramqFederationMaxHops default value of max relay count of one message
+	DefaultAuramqFederationMaxHops = 2
+	//DefaultAuramqFederationDedupWindow default value of time(second) of remembering delivered messages
+	DefaultAuramqFederationDedupWindow = 600
+	//DefaultAuramqSnapshotTopic default value of topic for requesting snapshot of miners
+	DefaultAuramqSnapshotTopic = "snapshot"
+	//DefaultAuramqSnapshotBatchSize default value of count of miners in one snapshot message
+	DefaultAuramqSnapshotBatchSize = 100
+	//DefaultAuramqSnapshotBatchInterval default value of interval(millisecond) between two snapshot messages
+	DefaultAuramqSnapshotBatchInterval = 10
+	//DefaultAuramqReplayTopic default value of topic for publishing updates with sequence number
+	DefaultAuramqReplayTopic = "sequenced"
+	//DefaultAuramqReplayRequestTopic default value of topic for requesting missed updates
+	DefaultAuramqReplayRequestTopic = "resume"
+	//DefaultAuramqReplayMaxSize default value of max size(MB) of retained updates
+	DefaultAuramqReplayMaxSize = 256
+	//DefaultAuramqReplayMaxCount default value of max count of retained updates
+	DefaultAuramqReplayMaxCount = 0
+	//DefaultAuramqReplayBatchSize default value of count of updates in one replay message
+	DefaultAuramqReplayBatchSize = 100
 	//DefaultAuramqClientClientID default value of client ID for identifying MQ client
 	DefaultAuramqClientClientID = "yottaminertracker"
 
@@ -195,9 +284,60 @@ var (
 	DefaultLoggerMaxAge int64 = 240
 	//DefaultLoggerLevel default value of LoggerLevel
 	DefaultLoggerLevel string = "Info"
+	//DefaultLoggerFormat default value of LoggerFormat
+	DefaultLoggerFormat string = "text"
+	//DefaultLoggerLevels default value of LoggerLevels
+	DefaultLoggerLevels string = ""
+
+	//DefaultAlertWebhookURL default value of URL receiving alerts
+	DefaultAlertWebhookURL string = ""
+	//DefaultAlertSecret default value of secret for signing alerts
+	DefaultAlertSecret string = ""
+	//DefaultAlertSecretFile default value of file containing secret for signing alerts
+	DefaultAlertSecretFile string = ""
+	//DefaultAlertTimeout default value of timeout(second) of webhook requests
+	DefaultAlertTimeout int = 10
+	//DefaultAlertRetries default value of retry count of failed webhook requests
+	DefaultAlertRetries int = 3
+	//DefaultAlertRetryInterval default value of interval(second) between retries of webhook requests
+	DefaultAlertRetryInterval int = 5
+	//DefaultAlertCheckInterval default value of interval(second) of checking silent miners and reloading rules
+	DefaultAlertCheckInterval int = 60
+
+	//DefaultOfflineThreshold default value of time(second) without report before miner is marked offline
+	DefaultOfflineThreshold int = 600
+	//DefaultOfflineInterval default value of interval(second) of detecting offline miners
+	DefaultOfflineInterval int = 60
+	//DefaultOfflineTopic default value of topic for publishing state changes of miners
+	DefaultOfflineTopic string = "state"
+
+	//DefaultReconcileSNURLs default value of mongoDB URLs of SNs whose shards are counted in uspace reconciliation
+	DefaultReconcileSNURLs = []string{}
+	//DefaultReconcileInterval default value of interval(second) of uspace reconciliation
+	DefaultReconcileInterval int = 86400
+	//DefaultReconcileBatchSize default value of count of shards read between two checkpoints of uspace reconciliation
+	DefaultReconcileBatchSize int = 500000
+	//DefaultReconcileShardDB default value of database name of shards collection in SN
+	DefaultReconcileShardDB string = "metabase"
+	//DefaultReconcileShardCollection default value of name of shards collection in SN
+	DefaultReconcileShardCollection string = "shards"
+	//DefaultReconcileWorkDB default value of prefix of database names for saving checkpoints of uspace reconciliation
+	DefaultReconcileWorkDB string = "reconcile"
 
 	//DefaultMiscRefreshAuthInterval default value of auth table refreshing interval
 	DefaultMiscRefreshAuthInterval int = 600
+	//DefaultMiscRefreshAuthWorkers default value of count of workers refreshing auth table concurrently
+	DefaultMiscRefreshAuthWorkers int = 8
+	//DefaultMiscAuthNegativeTTL default value of caching time of unknown accounts
+	DefaultMiscAuthNegativeTTL int = 60
+	//DefaultMiscAdminToken default value of token for accessing admin API
+	DefaultMiscAdminToken string = ""
+	//DefaultMiscInstanceID default value of ID of tracker instance
+	DefaultMiscInstanceID string = ""
+	//DefaultMiscLeaseTTL default value of leader lease TTL
+	DefaultMiscLeaseTTL int = 15
+	//DefaultMiscAutoMigrate default value of whether pending schema migrations are applied at startup
+	DefaultMiscAutoMigrate bool = true
 )
 
 func initFlag() {
@@ -208,6 +348,8 @@ func initFlag() {
 	viper.BindPFlag(yttracker.EOSURLField, rootCmd.PersistentFlags().Lookup(yttracker.EOSURLField))
 	rootCmd.PersistentFlags().String(yttracker.MongoDBURLField, DefaultMongoDBURL, "URL of mongoDB")
 	viper.BindPFlag(yttracker.MongoDBURLField, rootCmd.PersistentFlags().Lookup(yttracker.MongoDBURLField))
+	rootCmd.PersistentFlags().String(yttracker.MongoDBURLFileField, DefaultMongoDBURLFile, "file containing URL of mongoDB, overrides mongodb-url if specified")
+	viper.BindPFlag(yttracker.MongoDBURLFileField, rootCmd.PersistentFlags().Lookup(yttracker.MongoDBURLFileField))
 	//AuraMQ config
 	rootCmd.PersistentFlags().String(yttracker.AuramqServerBindAddrField, DefaultAuramqServerBindAddr, "binding address of AuraMQ server")
 	viper.BindPFlag(yttracker.AuramqServerBindAddrField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqServerBindAddrField))
@@ -237,16 +379,42 @@ func initFlag() {
 	viper.BindPFlag(yttracker.AuramqClientWriteWaitField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientWriteWaitField))
 	rootCmd.PersistentFlags().String(yttracker.AuramqClientMinerSyncTopicField, DefaultAuramqClientMinerSyncTopic, "client side miner-sync topic name")
 	viper.BindPFlag(yttracker.AuramqClientMinerSyncTopicField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientMinerSyncTopicField))
-	rootCmd.PersistentFlags().StringSlice(yttracker.AuramqClientAllSNURLsField, DefaultAuramqClientAllSNURLs, "all AuraMQ URLs for connecting, in the form of --auramq.all-sn-urls \"URL1,URL2,URL3\"")
+	rootCmd.PersistentFlags().StringSlice(yttracker.AuramqClientAllSNURLsField, DefaultAuramqClientAllSNURLs, "all AuraMQ URLs for connecting with SN ID, in the form of --auramq.client.all-sn-urls \"ID1=URL1,ID2=URL2,ID3=URL3\"")
 	viper.BindPFlag(yttracker.AuramqClientAllSNURLsField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientAllSNURLsField))
 	rootCmd.PersistentFlags().String(yttracker.AuramqClientAccountField, DefaultAuramqClientAccount, "BP account for authenticating")
 	viper.BindPFlag(yttracker.AuramqClientAccountField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientAccountField))
 	rootCmd.PersistentFlags().String(yttracker.AuramqClientPrivateKeyField, DefaultAuramqClientPrivateKey, "private key of account for authenticating")
 	viper.BindPFlag(yttracker.AuramqClientPrivateKeyField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientPrivateKeyField))
+	rootCmd.PersistentFlags().String(yttracker.AuramqClientPrivateKeyFileField, DefaultAuramqClientPrivateKeyFile, "file containing private key of account for authenticating, overrides private-key if specified")
+	viper.BindPFlag(yttracker.AuramqClientPrivateKeyFileField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientPrivateKeyFileField))
 	rootCmd.PersistentFlags().String(yttracker.AuramqClientClientIDField, DefaultAuramqClientClientID, "client ID for identifying MQ client")
 	viper.BindPFlag(yttracker.AuramqClientClientIDField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqClientClientIDField))
+	rootCmd.PersistentFlags().StringSlice(yttracker.AuramqFederationPeersField, DefaultAuramqFederationPeers, "MQ URLs of peer trackers for relaying miner information, in the form of --auramq.federation.peers \"URL1,URL2,URL3\"")
+	viper.BindPFlag(yttracker.AuramqFederationPeersField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqFederationPeersField))
+	rootCmd.PersistentFlags().String(yttracker.AuramqFederationTopicField, DefaultAuramqFederationTopic, "topic for relaying miner information between peers")
+	viper.BindPFlag(yttracker.AuramqFederationTopicField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqFederationTopicField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqFederationMaxHopsField, DefaultAuramqFederationMaxHops, "max relay count of one message between peers")
+	viper.BindPFlag(yttracker.AuramqFederationMaxHopsField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqFederationMaxHopsField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqFederationDedupWindowField, DefaultAuramqFederationDedupWindow, "time(second) of remembering relayed messages for deduplication")
+	viper.BindPFlag(yttracker.AuramqFederationDedupWindowField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqFederationDedupWindowField))
+	rootCmd.PersistentFlags().String(yttracker.AuramqSnapshotTopicField, DefaultAuramqSnapshotTopic, "topic for requesting snapshot of miners")
+	viper.BindPFlag(yttracker.AuramqSnapshotTopicField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqSnapshotTopicField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqSnapshotBatchSizeField, DefaultAuramqSnapshotBatchSize, "count of miners in one snapshot message")
+	viper.BindPFlag(yttracker.AuramqSnapshotBatchSizeField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqSnapshotBatchSizeField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqSnapshotBatchIntervalField, DefaultAuramqSnapshotBatchInterval, "interval(millisecond) between two snapshot messages")
+	viper.BindPFlag(yttracker.AuramqSnapshotBatchIntervalField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqSnapshotBatchIntervalField))
+	rootCmd.PersistentFlags().String(yttracker.AuramqReplayTopicField, DefaultAuramqReplayTopic, "topic for publishing updates with sequence number")
+	viper.BindPFlag(yttracker.AuramqReplayTopicField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqReplayTopicField))
+	rootCmd.PersistentFlags().String(yttracker.AuramqReplayRequestTopicField, DefaultAuramqReplayRequestTopic, "topic for requesting missed updates")
+	viper.BindPFlag(yttracker.AuramqReplayRequestTopicField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqReplayRequestTopicField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqReplayMaxSizeField, DefaultAuramqReplayMaxSize, "max size(MB) of retained updates")
+	viper.BindPFlag(yttracker.AuramqReplayMaxSizeField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqReplayMaxSizeField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqReplayMaxCountField, DefaultAuramqReplayMaxCount, "max count of retained updates, 0 means unlimited")
+	viper.BindPFlag(yttracker.AuramqReplayMaxCountField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqReplayMaxCountField))
+	rootCmd.PersistentFlags().Int(yttracker.AuramqReplayBatchSizeField, DefaultAuramqReplayBatchSize, "count of updates in one replay message")
+	viper.BindPFlag(yttracker.AuramqReplayBatchSizeField, rootCmd.PersistentFlags().Lookup(yttracker.AuramqReplayBatchSizeField))
 	//MinerStat config
-	rootCmd.PersistentFlags().StringSlice(yttracker.MinerStatAllSyncURLsField, DefaultMinerStatAllSyncURLs, "all URLs of sync services, in the form of --miner-stat.all-sync-urls \"URL1,URL2,URL3\"")
+	rootCmd.PersistentFlags().StringSlice(yttracker.MinerStatAllSyncURLsField, DefaultMinerStatAllSyncURLs, "all URLs of sync services with SN ID, in the form of --miner-stat.all-sync-urls \"ID1=URL1,ID2=URL2,ID3=URL3\"")
 	viper.BindPFlag(yttracker.MinerStatAllSyncURLsField, rootCmd.PersistentFlags().Lookup(yttracker.MinerStatAllSyncURLsField))
 	rootCmd.PersistentFlags().Int(yttracker.MinerStatBatchSizeField, DefaultMinerStatBatchSize, "batch size when fetching miner logs")
 	viper.BindPFlag(yttracker.MinerStatBatchSizeField, rootCmd.PersistentFlags().Lookup(yttracker.MinerStatBatchSizeField))
@@ -255,7 +423,7 @@ func initFlag() {
 	rootCmd.PersistentFlags().Int(yttracker.MinerStatSkipTimeField, DefaultMinerStatSkipTime, "ensure not to fetching miner logs till the end")
 	viper.BindPFlag(yttracker.MinerStatSkipTimeField, rootCmd.PersistentFlags().Lookup(yttracker.MinerStatSkipTimeField))
 	//logger config
-	rootCmd.PersistentFlags().String(yttracker.LoggerOutputField, DefaultLoggerOutput, "Output type of logger(stdout or file)")
+	rootCmd.PersistentFlags().String(yttracker.LoggerOutputField, DefaultLoggerOutput, "Output type of logger(stdout, file or both separated by comma)")
 	viper.BindPFlag(yttracker.LoggerOutputField, rootCmd.PersistentFlags().Lookup(yttracker.LoggerOutputField))
 	rootCmd.PersistentFlags().String(yttracker.LoggerFilePathField, DefaultLoggerFilePath, "Output path of log file")
 	viper.BindPFlag(yttracker.LoggerFilePathField, rootCmd.PersistentFlags().Lookup(yttracker.LoggerFilePathField))
@@ -265,7 +433,58 @@ func initFlag() {
 	viper.BindPFlag(yttracker.LoggerMaxAgeField, rootCmd.PersistentFlags().Lookup(yttracker.LoggerMaxAgeField))
 	rootCmd.PersistentFlags().String(yttracker.LoggerLevelField, DefaultLoggerLevel, "Log level(Trace, Debug, Info, Warning, Error, Fatal, Panic)")
 	viper.BindPFlag(yttracker.LoggerLevelField, rootCmd.PersistentFlags().Lookup(yttracker.LoggerLevelField))
+	rootCmd.PersistentFlags().String(yttracker.LoggerFormatField, DefaultLoggerFormat, "Format of log(text or json)")
+	viper.BindPFlag(yttracker.LoggerFormatField, rootCmd.PersistentFlags().Lookup(yttracker.LoggerFormatField))
+	rootCmd.PersistentFlags().String(yttracker.LoggerLevelsField, DefaultLoggerLevels, "Log levels of components(sync, tracking, http, auth, alert) overriding log level, in the form of \"sync=debug,http=warn\"")
+	viper.BindPFlag(yttracker.LoggerLevelsField, rootCmd.PersistentFlags().Lookup(yttracker.LoggerLevelsField))
+	//Alert config
+	rootCmd.PersistentFlags().String(yttracker.AlertWebhookURLField, DefaultAlertWebhookURL, "URL receiving alerts of rules without webhook")
+	viper.BindPFlag(yttracker.AlertWebhookURLField, rootCmd.PersistentFlags().Lookup(yttracker.AlertWebhookURLField))
+	rootCmd.PersistentFlags().String(yttracker.AlertSecretField, DefaultAlertSecret, "secret for signing alerts with HMAC-SHA256, alerts are not signed if empty")
+	viper.BindPFlag(yttracker.AlertSecretField, rootCmd.PersistentFlags().Lookup(yttracker.AlertSecretField))
+	rootCmd.PersistentFlags().String(yttracker.AlertSecretFileField, DefaultAlertSecretFile, "file containing secret for signing alerts, overrides alert.secret")
+	viper.BindPFlag(yttracker.AlertSecretFileField, rootCmd.PersistentFlags().Lookup(yttracker.AlertSecretFileField))
+	rootCmd.PersistentFlags().Int(yttracker.AlertTimeoutField, DefaultAlertTimeout, "timeout(second) of webhook requests")
+	viper.BindPFlag(yttracker.AlertTimeoutField, rootCmd.PersistentFlags().Lookup(yttracker.AlertTimeoutField))
+	rootCmd.PersistentFlags().Int(yttracker.AlertRetriesField, DefaultAlertRetries, "retry count of failed webhook requests")
+	viper.BindPFlag(yttracker.AlertRetriesField, rootCmd.PersistentFlags().Lookup(yttracker.AlertRetriesField))
+	rootCmd.PersistentFlags().Int(yttracker.AlertRetryIntervalField, DefaultAlertRetryInterval, "interval(second) between retries of webhook requests")
+	viper.BindPFlag(yttracker.AlertRetryIntervalField, rootCmd.PersistentFlags().Lookup(yttracker.AlertRetryIntervalField))
+	rootCmd.PersistentFlags().Int(yttracker.AlertCheckIntervalField, DefaultAlertCheckInterval, "interval(second) of checking silent miners and reloading alert rules from database")
+	viper.BindPFlag(yttracker.AlertCheckIntervalField, rootCmd.PersistentFlags().Lookup(yttracker.AlertCheckIntervalField))
+	//Offline detection config
+	rootCmd.PersistentFlags().Int(yttracker.OfflineThresholdField, DefaultOfflineThreshold, "time(second) without report before miner is marked offline")
+	viper.BindPFlag(yttracker.OfflineThresholdField, rootCmd.PersistentFlags().Lookup(yttracker.OfflineThresholdField))
+	rootCmd.PersistentFlags().Int(yttracker.OfflineIntervalField, DefaultOfflineInterval, "interval(second) of detecting offline miners")
+	viper.BindPFlag(yttracker.OfflineIntervalField, rootCmd.PersistentFlags().Lookup(yttracker.OfflineIntervalField))
+	rootCmd.PersistentFlags().String(yttracker.OfflineTopicField, DefaultOfflineTopic, "topic for publishing state changes of miners")
+	viper.BindPFlag(yttracker.OfflineTopicField, rootCmd.PersistentFlags().Lookup(yttracker.OfflineTopicField))
+	//Uspace reconciliation config
+	rootCmd.PersistentFlags().StringSlice(yttracker.ReconcileSNURLsField, DefaultReconcileSNURLs, "mongoDB URLs of SNs whose shards are counted in uspace reconciliation, in the form of --reconcile.sn-urls \"ID1=URL1,ID2=URL2\"")
+	viper.BindPFlag(yttracker.ReconcileSNURLsField, rootCmd.PersistentFlags().Lookup(yttracker.ReconcileSNURLsField))
+	rootCmd.PersistentFlags().Int(yttracker.ReconcileIntervalField, DefaultReconcileInterval, "interval(second) of uspace reconciliation")
+	viper.BindPFlag(yttracker.ReconcileIntervalField, rootCmd.PersistentFlags().Lookup(yttracker.ReconcileIntervalField))
+	rootCmd.PersistentFlags().Int(yttracker.ReconcileBatchSizeField, DefaultReconcileBatchSize, "count of shards read between two checkpoints of uspace reconciliation")
+	viper.BindPFlag(yttracker.ReconcileBatchSizeField, rootCmd.PersistentFlags().Lookup(yttracker.ReconcileBatchSizeField))
+	rootCmd.PersistentFlags().String(yttracker.ReconcileShardDBField, DefaultReconcileShardDB, "database name of shards collection in SN")
+	viper.BindPFlag(yttracker.ReconcileShardDBField, rootCmd.PersistentFlags().Lookup(yttracker.ReconcileShardDBField))
+	rootCmd.PersistentFlags().String(yttracker.ReconcileShardTabField, DefaultReconcileShardCollection, "name of shards collection in SN")
+	viper.BindPFlag(yttracker.ReconcileShardTabField, rootCmd.PersistentFlags().Lookup(yttracker.ReconcileShardTabField))
+	rootCmd.PersistentFlags().String(yttracker.ReconcileWorkDBField, DefaultReconcileWorkDB, "prefix of database names for saving checkpoints of uspace reconciliation, <prefix>_sn<ID> is used for each SN")
+	viper.BindPFlag(yttracker.ReconcileWorkDBField, rootCmd.PersistentFlags().Lookup(yttracker.ReconcileWorkDBField))
 	//Misc config
 	rootCmd.PersistentFlags().Int(yttracker.MiscRefreshAuthIntervalField, DefaultMiscRefreshAuthInterval, "auth table refreshing interval")
 	viper.BindPFlag(yttracker.MiscRefreshAuthIntervalField, rootCmd.PersistentFlags().Lookup(yttracker.MiscRefreshAuthIntervalField))
+	rootCmd.PersistentFlags().Int(yttracker.MiscRefreshAuthWorkersField, DefaultMiscRefreshAuthWorkers, "count of workers refreshing auth table concurrently")
+	viper.BindPFlag(yttracker.MiscRefreshAuthWorkersField, rootCmd.PersistentFlags().Lookup(yttracker.MiscRefreshAuthWorkersField))
+	rootCmd.PersistentFlags().Int(yttracker.MiscAuthNegativeTTLField, DefaultMiscAuthNegativeTTL, "caching time(second) of unknown accounts when authenticating")
+	viper.BindPFlag(yttracker.MiscAuthNegativeTTLField, rootCmd.PersistentFlags().Lookup(yttracker.MiscAuthNegativeTTLField))
+	rootCmd.PersistentFlags().String(yttracker.MiscAdminTokenField, DefaultMiscAdminToken, "token for accessing admin API, admin API is disabled if empty")
+	viper.BindPFlag(yttracker.MiscAdminTokenField, rootCmd.PersistentFlags().Lookup(yttracker.MiscAdminTokenField))
+	rootCmd.PersistentFlags().String(yttracker.MiscInstanceIDField, DefaultMiscInstanceID, "ID of tracker instance used in leader election, default is <hostname>-<pid>")
+	viper.BindPFlag(yttracker.MiscInstanceIDField, rootCmd.PersistentFlags().Lookup(yttracker.MiscInstanceIDField))
+	rootCmd.PersistentFlags().Int(yttracker.MiscLeaseTTLField, DefaultMiscLeaseTTL, "time(second) before leader lease expires if not renewed")
+	viper.BindPFlag(yttracker.MiscLeaseTTLField, rootCmd.PersistentFlags().Lookup(yttracker.MiscLeaseTTLField))
+	rootCmd.PersistentFlags().Bool(yttracker.MiscAutoMigrateField, DefaultMiscAutoMigrate, "apply pending schema migrations at startup")
+	viper.BindPFlag(yttracker.MiscAutoMigrateField, rootCmd.PersistentFlags().Lookup(yttracker.MiscAutoMigrateField))
 }
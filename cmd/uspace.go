@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	yttracker "github.com/yottachain/yotta-miner-tracker"
+)
+
+var (
+	uspaceSNURL     string
+	uspaceShardDB   string
+	uspaceShardTab  string
+	uspaceNodeDB    string
+	uspaceNodeTab   string
+	uspaceWorkDB    string
+	uspaceSNID      int
+	uspaceBatchSize int64
+	uspaceDryRun    bool
+	uspaceResetDel  bool
+)
+
+// correctUspaceCmd represents the correct-uspace command
+var correctUspaceCmd = &cobra.Command{
+	Use:   "correct-uspace",
+	Short: "correct used space of miners by counting shards",
+	Long:  `correct uspaces.snN field of miners in SN database by counting shards of each miner, interrupted correction can be resumed by running this command again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		initLog(config)
+		snCli := connectMongoURL(uspaceSNURL)
+		defer snCli.Disconnect(context.Background())
+		snID := uspaceSNID
+		if snID < 0 {
+			id, err := yttracker.GetSNID(context.Background(), snCli, uspaceNodeDB)
+			if err != nil {
+				fmt.Printf("read SN ID failed: %s\n", err)
+				os.Exit(1)
+			}
+			snID = id
+		}
+		fmt.Printf("current SN ID: %d\n", snID)
+		discrepancies, err := yttracker.CorrectUspace(context.Background(), &yttracker.UspaceCorrection{
+			ShardCli:        snCli,
+			ShardDB:         uspaceShardDB,
+			ShardCollection: uspaceShardTab,
+			NodeCli:         snCli,
+			NodeDB:          uspaceNodeDB,
+			NodeCollection:  uspaceNodeTab,
+			WorkDB:          uspaceWorkDB,
+			SNID:            snID,
+			ResetDel:        uspaceResetDel,
+			BatchSize:       uspaceBatchSize,
+			DryRun:          uspaceDryRun,
+		})
+		for _, d := range discrepancies {
+			fmt.Printf("miner %d\tuspace: %d\tcalculated: %d\tdelta: %d\tapplied: %t\n", d.MinerID, d.Space, d.Calculated, d.Calculated-d.Space, d.Applied)
+		}
+		if err != nil {
+			fmt.Printf("correcting uspace failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d miners with discrepancy found\n", len(discrepancies))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(correctUspaceCmd)
+
+	correctUspaceCmd.Flags().StringVar(&uspaceSNURL, "sn-url", "mongodb://127.0.0.1:27017/?connect=direct", "URL of SN mongoDB")
+	correctUspaceCmd.Flags().StringVar(&uspaceShardDB, "shard-db", "metabase", "database name of shards collection")
+	correctUspaceCmd.Flags().StringVar(&uspaceShardTab, "shard-collection", "shards", "name of shards collection")
+	correctUspaceCmd.Flags().StringVar(&uspaceNodeDB, "node-db", "yotta", "database name of node collection")
+	correctUspaceCmd.Flags().StringVar(&uspaceNodeTab, "node-collection", "Node", "name of node collection")
+	correctUspaceCmd.Flags().StringVar(&uspaceWorkDB, "work-db", "uspace_correction", "database name for saving checkpoints and intermediate results")
+	correctUspaceCmd.Flags().IntVar(&uspaceSNID, "sn-id", -1, "ID of SN whose used space will be corrected, read from sequence collection of node-db if not specified")
+	correctUspaceCmd.Flags().Int64Var(&uspaceBatchSize, "batch-size", 500000, "count of shards read between two checkpoints")
+	correctUspaceCmd.Flags().BoolVar(&uspaceDryRun, "dry-run", false, "only print discrepancies without modifying node collection")
+	correctUspaceCmd.Flags().BoolVar(&uspaceResetDel, "reset-del", false, "set uspaces.del field of all nodes to 0 before counting")
+}
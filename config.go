@@ -1,5 +1,15 @@
 package yttracker
 
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
 //Field names of config file
 const (
 	//bind address of http server
@@ -8,6 +18,8 @@ const (
 	EOSURLField = "eos-url"
 	//URL of mongo DB
 	MongoDBURLField = "mongodb-url"
+	//file containing URL of mongo DB
+	MongoDBURLFileField = "mongodb-url-file"
 
 	//config of MQ
 	AuramqServerBindAddrField             = "auramq.server.bind-addr"
@@ -28,7 +40,20 @@ const (
 	AuramqClientAllSNURLsField            = "auramq.client.all-sn-urls"
 	AuramqClientAccountField              = "auramq.client.account"
 	AuramqClientPrivateKeyField           = "auramq.client.private-key"
+	AuramqClientPrivateKeyFileField       = "auramq.client.private-key-file"
 	AuramqClientClientIDField             = "auramq.client.client-id"
+	AuramqFederationPeersField            = "auramq.federation.peers"
+	AuramqFederationTopicField            = "auramq.federation.topic"
+	AuramqFederationMaxHopsField          = "auramq.federation.max-hops"
+	AuramqFederationDedupWindowField      = "auramq.federation.dedup-window"
+	AuramqSnapshotTopicField              = "auramq.snapshot.topic"
+	AuramqSnapshotBatchSizeField          = "auramq.snapshot.batch-size"
+	AuramqSnapshotBatchIntervalField      = "auramq.snapshot.batch-interval"
+	AuramqReplayTopicField                = "auramq.replay.topic"
+	AuramqReplayRequestTopicField         = "auramq.replay.request-topic"
+	AuramqReplayMaxSizeField              = "auramq.replay.max-size"
+	AuramqReplayMaxCountField             = "auramq.replay.max-count"
+	AuramqReplayBatchSizeField            = "auramq.replay.batch-size"
 
 	//MinerStat config
 	MinerStatAllSyncURLsField = "miner-stat.all-sync-urls"
@@ -42,26 +67,110 @@ const (
 	LoggerRotationTimeField = "logger.rotation-time"
 	LoggerMaxAgeField       = "logger.max-age"
 	LoggerLevelField        = "logger.level"
+	LoggerFormatField       = "logger.format"
+	LoggerLevelsField       = "logger.levels"
+
+	//Alert config
+	AlertWebhookURLField    = "alert.webhook-url"
+	AlertSecretField        = "alert.secret"
+	AlertSecretFileField    = "alert.secret-file"
+	AlertTimeoutField       = "alert.timeout"
+	AlertRetriesField       = "alert.retries"
+	AlertRetryIntervalField = "alert.retry-interval"
+	AlertCheckIntervalField = "alert.check-interval"
+	AlertRulesField         = "alert.rules"
+
+	//Offline detection config
+	OfflineThresholdField = "offline.threshold"
+	OfflineIntervalField  = "offline.interval"
+	OfflineTopicField     = "offline.topic"
+
+	//Uspace reconciliation config
+	ReconcileSNURLsField    = "reconcile.sn-urls"
+	ReconcileIntervalField  = "reconcile.interval"
+	ReconcileBatchSizeField = "reconcile.batch-size"
+	ReconcileShardDBField   = "reconcile.shard-db"
+	ReconcileShardTabField  = "reconcile.shard-collection"
+	ReconcileWorkDBField    = "reconcile.work-db"
+
+	//Extra indexes config
+	IndexesField = "indexes"
 
 	//Misc config
 	MiscRefreshAuthIntervalField = "misc.refresh-auth-interval"
+	MiscRefreshAuthWorkersField  = "misc.refresh-auth-workers"
+	MiscAuthNegativeTTLField     = "misc.auth-negative-ttl"
+	MiscAdminTokenField          = "misc.admin-token"
+	MiscInstanceIDField          = "misc.instance-id"
+	MiscLeaseTTLField            = "misc.lease-ttl"
+	MiscAutoMigrateField         = "misc.auto-migrate"
 )
 
 //Config system configuration
 type Config struct {
-	HTTPBindAddr string           `mapstructure:"http-bind-addr"`
-	EOSURL       string           `mapstructure:"eos-url"`
-	MongoDBURL   string           `mapstructure:"mongodb-url"`
-	AuraMQ       *AuraMQConfig    `mapstructure:"auramq"`
-	MinerStat    *MinerStatConfig `mapstructure:"miner-stat"`
-	Logger       *LogConfig       `mapstructure:"logger"`
-	Misc         *MiscConfig      `mapstructure:"misc"`
+	HTTPBindAddr   string           `mapstructure:"http-bind-addr"`
+	EOSURL         string           `mapstructure:"eos-url"`
+	MongoDBURL     string           `mapstructure:"mongodb-url"`
+	MongoDBURLFile string           `mapstructure:"mongodb-url-file"`
+	AuraMQ         *AuraMQConfig    `mapstructure:"auramq"`
+	MinerStat      *MinerStatConfig `mapstructure:"miner-stat"`
+	Logger         *LogConfig       `mapstructure:"logger"`
+	Alert          *AlertConfig     `mapstructure:"alert"`
+	Offline        *OfflineConfig   `mapstructure:"offline"`
+	Reconcile      *ReconcileConfig `mapstructure:"reconcile"`
+	Indexes        []*IndexSpec     `mapstructure:"indexes"`
+	Misc           *MiscConfig      `mapstructure:"misc"`
+}
+
+//LoadSecretFiles read mongoDB URL, private key and webhook secret from files if specified, values in files take precedence,
+//returns a ConfigError containing all secret files failed to read
+func (config *Config) LoadSecretFiles() error {
+	e := new(ConfigError)
+	if config.MongoDBURLFile != "" {
+		value, err := readSecretFile(config.MongoDBURLFile)
+		if err != nil {
+			e.addf("%s: %s", MongoDBURLFileField, err)
+		} else {
+			config.MongoDBURL = value
+		}
+	}
+	if config.AuraMQ != nil && config.AuraMQ.ClientConfig != nil && config.AuraMQ.ClientConfig.PrivateKeyFile != "" {
+		value, err := readSecretFile(config.AuraMQ.ClientConfig.PrivateKeyFile)
+		if err != nil {
+			e.addf("%s: %s", AuramqClientPrivateKeyFileField, err)
+		} else {
+			config.AuraMQ.ClientConfig.PrivateKey = value
+		}
+	}
+	if config.Alert != nil && config.Alert.SecretFile != "" {
+		value, err := readSecretFile(config.Alert.SecretFile)
+		if err != nil {
+			e.addf("%s: %s", AlertSecretFileField, err)
+		} else {
+			config.Alert.Secret = value
+		}
+	}
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
 }
 
 //AuraMQConfig auramq configuration
 type AuraMQConfig struct {
-	ServerConfig *ServerConfig `mapstructure:"server"`
-	ClientConfig *ClientConfig `mapstructure:"client"`
+	ServerConfig *ServerConfig     `mapstructure:"server"`
+	ClientConfig *ClientConfig     `mapstructure:"client"`
+	Federation   *FederationConfig `mapstructure:"federation"`
+	Snapshot     *SnapshotConfig   `mapstructure:"snapshot"`
+	Replay       *ReplayConfig     `mapstructure:"replay"`
 }
 
 //ServerConfig server config of AuraMQ
@@ -79,35 +188,188 @@ type ServerConfig struct {
 
 //ClientConfig client config of AuraMQ
 type ClientConfig struct {
-	SubscriberBufferSize int      `mapstructure:"subscriber-buffer-size"`
-	PingWait             int      `mapstructure:"ping-wait"`
-	ReadWait             int      `mapstructure:"read-wait"`
-	WriteWait            int      `mapstructure:"write-wait"`
-	MinerSyncTopic       string   `mapstructure:"miner-sync-topic"`
-	AllSNURLs            []string `mapstructure:"all-sn-urls"`
-	Account              string   `mapstructure:"account"`
-	PrivateKey           string   `mapstructure:"private-key"`
-	ClientID             string   `mapstructure:"client-id"`
+	SubscriberBufferSize int           `mapstructure:"subscriber-buffer-size"`
+	PingWait             int           `mapstructure:"ping-wait"`
+	ReadWait             int           `mapstructure:"read-wait"`
+	WriteWait            int           `mapstructure:"write-wait"`
+	MinerSyncTopic       string        `mapstructure:"miner-sync-topic"`
+	AllSNURLs            []*SNEndpoint `mapstructure:"all-sn-urls"`
+	Account              string        `mapstructure:"account"`
+	PrivateKey           string        `mapstructure:"private-key"`
+	PrivateKeyFile       string        `mapstructure:"private-key-file"`
+	ClientID             string        `mapstructure:"client-id"`
+}
+
+//FederationConfig config of relaying miner information between tracker instances
+type FederationConfig struct {
+	Peers       []string `mapstructure:"peers"`
+	Topic       string   `mapstructure:"topic"`
+	MaxHops     int      `mapstructure:"max-hops"`
+	DedupWindow int      `mapstructure:"dedup-window"`
+}
+
+//SnapshotConfig config of sending snapshot of miners to MQ subscribers
+type SnapshotConfig struct {
+	Topic         string `mapstructure:"topic"`
+	BatchSize     int    `mapstructure:"batch-size"`
+	BatchInterval int    `mapstructure:"batch-interval"`
+}
+
+//ReplayConfig config of retaining published updates for subscribers resuming after disconnection
+type ReplayConfig struct {
+	Topic        string `mapstructure:"topic"`
+	RequestTopic string `mapstructure:"request-topic"`
+	MaxSize      int    `mapstructure:"max-size"`
+	MaxCount     int    `mapstructure:"max-count"`
+	BatchSize    int    `mapstructure:"batch-size"`
+}
+
+//SNEndpoint service endpoint of SN identified by SN ID, miners reported by MQ server of SN can only update
+//the key of uspaces specified by UspaceKey
+type SNEndpoint struct {
+	ID        int32  `mapstructure:"id"`
+	URL       string `mapstructure:"url"`
+	UspaceKey string `mapstructure:"uspace-key"`
+}
+
+//UspacesKey returns key of uspaces owned by SN, default is sn<SN ID>
+func (ep *SNEndpoint) UspacesKey() string {
+	if ep.UspaceKey != "" {
+		return ep.UspaceKey
+	}
+	return fmt.Sprintf("sn%d", ep.ID)
 }
 
 //MinerStatConfig miner log sync configuration
 type MinerStatConfig struct {
-	AllSyncURLs []string `mapstructure:"all-sync-urls"`
-	BatchSize   int      `mapstructure:"batch-size"`
-	WaitTime    int      `mapstructure:"wait-time"`
-	SkipTime    int      `mapstructure:"skip-time"`
+	AllSyncURLs []*SNEndpoint `mapstructure:"all-sync-urls"`
+	BatchSize   int           `mapstructure:"batch-size"`
+	WaitTime    int           `mapstructure:"wait-time"`
+	SkipTime    int           `mapstructure:"skip-time"`
 }
 
 //LogConfig system log configuration
 type LogConfig struct {
-	Output       string `mapstructure:"output"`
-	FilePath     string `mapstructure:"file-path"`
-	RotationTime int64  `mapstructure:"rotation-time"`
-	MaxAge       int64  `mapstructure:"max-age"`
-	Level        string `mapstructure:"level"`
+	Output       string            `mapstructure:"output"`
+	FilePath     string            `mapstructure:"file-path"`
+	RotationTime int64             `mapstructure:"rotation-time"`
+	MaxAge       int64             `mapstructure:"max-age"`
+	Level        string            `mapstructure:"level"`
+	Format       string            `mapstructure:"format"`
+	Levels       map[string]string `mapstructure:"levels"`
+}
+
+//Outputs all outputs of logger, multiple outputs are separated by comma
+func (conf *LogConfig) Outputs() []string {
+	outputs := make([]string, 0)
+	for _, output := range strings.Split(conf.Output, ",") {
+		output = strings.ToLower(strings.TrimSpace(output))
+		if output != "" {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs
+}
+
+//AlertConfig webhook alerting configuration
+type AlertConfig struct {
+	WebhookURL    string       `mapstructure:"webhook-url"`
+	Secret        string       `mapstructure:"secret"`
+	SecretFile    string       `mapstructure:"secret-file"`
+	Timeout       int          `mapstructure:"timeout"`
+	Retries       int          `mapstructure:"retries"`
+	RetryInterval int          `mapstructure:"retry-interval"`
+	CheckInterval int          `mapstructure:"check-interval"`
+	Rules         []*AlertRule `mapstructure:"rules"`
+}
+
+//OfflineConfig offline detection configuration
+type OfflineConfig struct {
+	Threshold int    `mapstructure:"threshold"`
+	Interval  int    `mapstructure:"interval"`
+	Topic     string `mapstructure:"topic"`
+}
+
+//ReconcileConfig uspace reconciliation configuration, used spaces of miners are reconciled with shards in
+//mongoDB of each SN in SNURLs, reconciliation is disabled if SNURLs is empty
+type ReconcileConfig struct {
+	SNURLs          []*SNEndpoint `mapstructure:"sn-urls"`
+	Interval        int           `mapstructure:"interval"`
+	BatchSize       int           `mapstructure:"batch-size"`
+	ShardDB         string        `mapstructure:"shard-db"`
+	ShardCollection string        `mapstructure:"shard-collection"`
+	WorkDB          string        `mapstructure:"work-db"`
 }
 
 //MiscConfig miscellaneous configuration
 type MiscConfig struct {
-	RefreshAuthInterval int `mapstructure:"refresh-auth-interval"`
+	RefreshAuthInterval int    `mapstructure:"refresh-auth-interval"`
+	RefreshAuthWorkers  int    `mapstructure:"refresh-auth-workers"`
+	AuthNegativeTTL     int    `mapstructure:"auth-negative-ttl"`
+	AdminToken          string `mapstructure:"admin-token"`
+	InstanceID          string `mapstructure:"instance-id"`
+	LeaseTTL            int    `mapstructure:"lease-ttl"`
+	AutoMigrate         bool   `mapstructure:"auto-migrate"`
+}
+
+//StringToSNEndpointHookFunc returns a decode hook converting string in the form of "ID=URL" to SNEndpoint,
+//which is used when endpoints are specified by command line flags
+func StringToSNEndpointHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || (t != reflect.TypeOf(SNEndpoint{}) && t != reflect.TypeOf(&SNEndpoint{})) {
+			return data, nil
+		}
+		str := data.(string)
+		idx := strings.Index(str, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("SN endpoint must be in the form of ID=URL: %s", str)
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(str[0:idx]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SN ID of endpoint %s: %s", str, err)
+		}
+		return map[string]interface{}{"id": id, "url": strings.TrimSpace(str[idx+1:])}, nil
+	}
+}
+
+//StringToStringMapHookFunc returns a decode hook converting string in the form of "k1=v1,k2=v2" to map,
+//which is used when map is specified by command line flags or environment variables
+func StringToStringMapHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(map[string]string{}) {
+			return data, nil
+		}
+		m := make(map[string]string)
+		for _, pair := range strings.Split(data.(string), ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			idx := strings.Index(pair, "=")
+			if idx <= 0 {
+				return nil, fmt.Errorf("map entry must be in the form of key=value: %s", pair)
+			}
+			m[strings.TrimSpace(pair[0:idx])] = strings.TrimSpace(pair[idx+1:])
+		}
+		return m, nil
+	}
+}
+
+//CheckSNEndpoints check if there are duplicate SN IDs in endpoints
+func CheckSNEndpoints(endpoints []*SNEndpoint) error {
+	ids := make(map[int32]bool)
+	keys := make(map[string]bool)
+	for _, ep := range endpoints {
+		if ep == nil {
+			return fmt.Errorf("SN endpoint cannot be empty")
+		}
+		if ids[ep.ID] {
+			return fmt.Errorf("duplicate SN ID: %d", ep.ID)
+		}
+		ids[ep.ID] = true
+		if keys[ep.UspacesKey()] {
+			return fmt.Errorf("duplicate uspace key: %s", ep.UspacesKey())
+		}
+		keys[ep.UspacesKey()] = true
+	}
+	return nil
 }
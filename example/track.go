@@ -32,10 +32,36 @@ func main() {
 		panic(err)
 	}
 	//开始监听
-	cli.Run()
+	go cli.Run()
+	//订阅后请求全部矿机的快照，snapshot为服务端auramq.snapshot.topic配置的队列名称，filter为可选的查询条件，格式与/query相同
+	req, err := proto.Marshal(&pb.SnapshotReq{RequestID: "testclient-1", Filter: ""})
+	if err != nil {
+		panic(err)
+	}
+	cli.Publish("snapshot", req)
+	select {}
 }
 
 func callback(msg *msg.Message) {
+	//快照以点对点消息分批发送，最后一批的End属性为true
+	if msg.GetType() == auramq.P2P {
+		snapshot := new(pb.SnapshotMsg)
+		err := proto.Unmarshal(msg.Content, snapshot)
+		if err != nil {
+			fmt.Println("decoding snapshotMsg failed")
+			return
+		}
+		if snapshot.Error != "" {
+			fmt.Printf("snapshot failed: %s\n", snapshot.Error)
+			return
+		}
+		//与实时消息合并时应保留timestamp较新的矿机信息
+		fmt.Printf("received %d nodes in snapshot\n", len(snapshot.Nodes))
+		if snapshot.End {
+			fmt.Printf("snapshot finished, %d nodes in total\n", snapshot.Total)
+		}
+		return
+	}
 	//判断消息是否为广播消息且为要监听的队列
 	if msg.GetType() == auramq.BROADCAST && msg.GetDestination() == "sync" {
 		nodemsg := new(pb.NodeMsg)
@@ -51,5 +77,9 @@ func callback(msg *msg.Message) {
 			return
 		}
 		fmt.Printf("received node %d\n", node.ID)
+		//SN发送的扩展字段
+		for k, v := range node.Ext {
+			fmt.Printf("extension of node %d: %s=%v\n", node.ID, k, v)
+		}
 	}
 }
@@ -0,0 +1,142 @@
+package yttracker
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aurawing/auramq"
+	"github.com/aurawing/auramq/msg"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+)
+
+//expired message IDs are purged when count of received message IDs reaches this value
+const maxSeenMessages = 100000
+
+//federation relays miner information between tracker instances, each instance publishes NodeMsg received from SN
+//to its federation topic wrapped in RelayMsg, and subscribes federation topic of peers, messages are delivered to
+//local subscribers and forwarded to peers of current instance until max hops reached, a relayed message identified by
+//its origin instance and sequence number is delivered only once no matter how many paths it is forwarded through
+type federation struct {
+	instanceID string
+	topic      string
+	maxHops    int32
+	window     int64
+	lock       sync.Mutex
+	seen       map[string]int64
+	peers      map[string]*mqLink
+}
+
+func newFederation(instanceID string, conf *FederationConfig) *federation {
+	return &federation{instanceID: instanceID, topic: conf.Topic, maxHops: int32(conf.MaxHops), window: int64(conf.DedupWindow), seen: make(map[string]int64), peers: make(map[string]*mqLink)}
+}
+
+//relayKey identity of relayed message, which is its origin instance with sequence number of the update, or with hash
+//of content if sequence number is not assigned
+func relayKey(m *pb.RelayMsg) string {
+	if m.Seq > 0 {
+		return fmt.Sprintf("%s/%d", m.Origin, m.Seq)
+	}
+	return fmt.Sprintf("%s/%x", m.Origin, sha256.Sum256(m.Content))
+}
+
+//isDuplicate check if relayed message identified by key has been delivered in dedup window and record it if not
+func (f *federation) isDuplicate(key string) bool {
+	now := time.Now().Unix()
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if expiration, ok := f.seen[key]; ok && expiration > now {
+		return true
+	}
+	if len(f.seen) >= maxSeenMessages {
+		for k, v := range f.seen {
+			if v <= now {
+				delete(f.seen, k)
+			}
+		}
+	}
+	f.seen[key] = now + f.window
+	return false
+}
+
+//relay publish NodeMsg received from SN to federation topic of current instance with its sequence number, which is
+//kept by peers when delivering it to their subscribers
+func (s *Service) relay(content []byte, seq int64) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "relay"})
+	f := s.federation
+	b, err := proto.Marshal(&pb.RelayMsg{Origin: f.instanceID, Hops: 0, Content: content, Seq: seq})
+	if err != nil {
+		entry.WithError(err).Error("encoding RelayMsg")
+		return
+	}
+	s.client.Publish(f.topic, b)
+}
+
+//receiveRelay deliver NodeMsg relayed by peers to local subscribers and forward it to peers of current instance
+func (s *Service) receiveRelay(m *msg.Message) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "receiveRelay"})
+	f := s.federation
+	if m.GetType() != auramq.BROADCAST || m.GetDestination() != f.topic {
+		return
+	}
+	relayMsg := new(pb.RelayMsg)
+	err := proto.Unmarshal(m.Content, relayMsg)
+	if err != nil {
+		entry.WithError(err).Error("decoding RelayMsg failed")
+		return
+	}
+	if relayMsg.Origin == f.instanceID {
+		return
+	}
+	nodeMsg := new(pb.NodeMsg)
+	err = proto.Unmarshal(relayMsg.Content, nodeMsg)
+	if err != nil {
+		entry.WithError(err).Error("decoding nodeMsg failed")
+		return
+	}
+	if f.isDuplicate(relayKey(relayMsg)) {
+		return
+	}
+	//relayed update keeps sequence number assigned by origin, which has retained it in update log shared by all instances
+	s.publishLocal(nodeMsg, relayMsg.Content)
+	relayMsg.Hops++
+	entry.WithFields(log.Fields{InstanceID: relayMsg.Origin, MinerID: nodeMsg.ID}).Debugf("relayed message received after %d hops", relayMsg.Hops)
+	if relayMsg.Hops >= f.maxHops {
+		return
+	}
+	b, err := proto.Marshal(relayMsg)
+	if err != nil {
+		entry.WithError(err).Error("encoding RelayMsg")
+		return
+	}
+	s.client.Publish(f.topic, b)
+}
+
+//UpdatePeers connect to MQ servers of added peers and disconnect from removed peers
+func (s *Service) UpdatePeers(peers []string) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "UpdatePeers"})
+	f := s.federation
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	urls := make(map[string]bool)
+	for _, url := range peers {
+		urls[url] = true
+	}
+	for url, link := range f.peers {
+		if !urls[url] {
+			link.stop()
+			delete(f.peers, url)
+			entry.Infof("disconnected from peer: %s", url)
+		}
+	}
+	for url := range urls {
+		if _, ok := f.peers[url]; !ok {
+			//client ID must be unique in MQ server of peer
+			clientID := fmt.Sprintf("%s-%s", s.clientConf.ClientID, f.instanceID)
+			f.peers[url] = s.connectMQ(entry.WithField("peer", url), "peer", url, clientID, f.topic, s.receiveRelay)
+		}
+	}
+}
@@ -6,7 +6,9 @@ require (
 	github.com/aurawing/auramq v0.0.2-0.20200521072017-845ffa488ac8
 	github.com/aurawing/eos-go v0.9.1-0.20200517054114-c338bd5d1974
 	github.com/fastly/go-utils v0.0.0-20180712184237-d95a45783239 // indirect
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/golang/protobuf v1.4.1
+	github.com/gorilla/websocket v1.4.2
 	github.com/jehiah/go-strftime v0.0.0-20171201141054-1d33003b3869 // indirect
 	github.com/labstack/echo v3.3.10+incompatible
 	github.com/labstack/gommon v0.3.0 // indirect
@@ -14,6 +16,7 @@ require (
 	github.com/lestrrat-go/strftime v1.0.1 // indirect
 	github.com/libp2p/go-libp2p-core v0.3.0 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/mitchellh/mapstructure v1.1.2
 	github.com/sirupsen/logrus v1.5.0
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/viper v1.6.3
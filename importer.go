@@ -0,0 +1,142 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//ImportProgressTab collection name of node importing progress
+var ImportProgressTab = "ImportProgress"
+
+//ImportProgress checkpoint of node importing task
+type ImportProgress struct {
+	ID        string `bson:"_id"`
+	LastID    int32  `bson:"lastID"`
+	Count     int64  `bson:"count"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+//NodeImport options of importing nodes from SN database
+type NodeImport struct {
+	//Task name of importing task, used as key of checkpoint
+	Task string
+	//SrcCli client of source SN database
+	SrcCli *mongo.Client
+	//SrcDB database name of source node collection
+	SrcDB string
+	//SrcCollection name of source node collection
+	SrcCollection string
+	//FieldMap mapping from source field name to destination field name, field mapped to "-" will be dropped
+	FieldMap map[string]string
+	//BatchSize count of nodes imported between two checkpoints
+	BatchSize int64
+	//Restart ignore checkpoint and import from the first node
+	Restart bool
+	//Progress called after each batch with count of imported nodes and total count of source nodes
+	Progress func(imported, total int64)
+}
+
+//ImportNodes copy nodes from SN database to node collection of tracker, existing nodes are updated by fields
+//of source node, and checkpoint is saved after each batch so that interrupted task can be resumed
+func ImportNodes(ctx context.Context, cli *mongo.Client, opt *NodeImport) (int64, error) {
+	entry := log.WithFields(log.Fields{Function: "ImportNodes"})
+	srcCollection := opt.SrcCli.Database(opt.SrcDB).Collection(opt.SrcCollection)
+	collection := cli.Database(MinerTrackerDB).Collection(NodeTab)
+	collectionProgress := cli.Database(MinerTrackerDB).Collection(ImportProgressTab)
+	progress := &ImportProgress{ID: opt.Task, LastID: 0}
+	if !opt.Restart {
+		err := collectionProgress.FindOne(ctx, bson.M{"_id": opt.Task}).Decode(progress)
+		if err != nil && err != mongo.ErrNoDocuments {
+			entry.WithError(err).Errorf("reading checkpoint of task %s", opt.Task)
+			return 0, err
+		}
+		if err == nil {
+			entry.Infof("resume task %s from miner %d, %d nodes have been imported", opt.Task, progress.LastID, progress.Count)
+		}
+	}
+	total, err := srcCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		entry.WithError(err).Error("counting source nodes")
+		return progress.Count, err
+	}
+	findOpts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(opt.BatchSize)
+	for {
+		cur, err := srcCollection.Find(ctx, bson.M{"_id": bson.M{"$gt": progress.LastID}}, findOpts)
+		if err != nil {
+			entry.WithError(err).Errorf("reading source nodes after miner %d", progress.LastID)
+			return progress.Count, err
+		}
+		count := 0
+		for cur.Next(ctx) {
+			doc := bson.M{}
+			err := cur.Decode(&doc)
+			if err != nil {
+				cur.Close(ctx)
+				entry.WithError(err).Errorf("decoding source node after miner %d", progress.LastID)
+				return progress.Count, err
+			}
+			id, ok := doc["_id"].(int32)
+			if !ok {
+				cur.Close(ctx)
+				err = fmt.Errorf("invalid ID of source node: %v", doc["_id"])
+				entry.WithError(err).Error("decoding source node")
+				return progress.Count, err
+			}
+			err = importNode(ctx, collection, id, doc, opt.FieldMap)
+			if err != nil {
+				cur.Close(ctx)
+				entry.WithError(err).Errorf("importing miner %d", id)
+				return progress.Count, err
+			}
+			progress.LastID = id
+			progress.Count++
+			count++
+		}
+		cur.Close(ctx)
+		if count == 0 {
+			break
+		}
+		progress.Timestamp = time.Now().Unix()
+		_, err = collectionProgress.ReplaceOne(ctx, bson.M{"_id": opt.Task}, progress, options.Replace().SetUpsert(true))
+		if err != nil {
+			entry.WithError(err).Errorf("saving checkpoint of task %s", opt.Task)
+			return progress.Count, err
+		}
+		if opt.Progress != nil {
+			opt.Progress(progress.Count, total)
+		}
+	}
+	entry.Infof("task %s finished, %d nodes imported", opt.Task, progress.Count)
+	return progress.Count, nil
+}
+
+func importNode(ctx context.Context, collection *mongo.Collection, id int32, doc bson.M, fieldMap map[string]string) error {
+	fields := bson.M{}
+	for k, v := range doc {
+		if k == "_id" {
+			continue
+		}
+		if name, ok := fieldMap[k]; ok {
+			if name == "-" {
+				continue
+			}
+			k = name
+		}
+		fields[k] = v
+	}
+	update := bson.M{}
+	if len(fields) > 0 {
+		update["$set"] = fields
+	}
+	if _, ok := fields["stableStat"]; !ok {
+		update["$setOnInsert"] = bson.M{"stableStat": &StableStatistics{StartTime: time.Now().Unix(), Counter: 0, Ratio: 1}}
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update, options.Update().SetUpsert(true))
+	return err
+}
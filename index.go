@@ -0,0 +1,223 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//IndexSpec index of collection in tracker database, keys are field names in order, prefixed with "-" for
+//descending order, name is generated from keys if empty
+type IndexSpec struct {
+	Collection string   `mapstructure:"collection"`
+	Keys       []string `mapstructure:"keys"`
+	Name       string   `mapstructure:"name"`
+	Unique     bool     `mapstructure:"unique"`
+	Sparse     bool     `mapstructure:"sparse"`
+}
+
+//status of index in index listing
+const (
+	IndexOK       = "ok"
+	IndexMissing  = "missing"
+	IndexExtra    = "extra"
+	IndexConflict = "conflict"
+)
+
+//IndexStatus existing and expected keys of one index
+type IndexStatus struct {
+	Collection string
+	Name       string
+	Expected   string
+	Existing   string
+	Status     string
+}
+
+//declaredIndexes indexes required by queries of tracker
+var declaredIndexes = []*IndexSpec{
+	{Collection: NodeTab, Keys: []string{"owner"}},
+	{Collection: NodeTab, Keys: []string{"poolID"}},
+	{Collection: NodeTab, Keys: []string{"status"}},
+	{Collection: NodeTab, Keys: []string{"timestamp"}},
+	{Collection: NodeTab, Keys: []string{"stableStat.ratio"}},
+	{Collection: NodeTab, Keys: []string{"offlineSince"}},
+	{Collection: AuthTab, Keys: []string{"_id"}, Name: "_id_"},
+	{Collection: TrackProgressTab, Keys: []string{"_id"}, Name: "_id_"},
+	{Collection: TrackProgressTab, Keys: []string{"url"}},
+	{Collection: UpdateLogTab, Keys: []string{"seq"}},
+}
+
+//Check check whether collection and keys are set
+func (spec *IndexSpec) Check() error {
+	if spec.Collection == "" {
+		return fmt.Errorf("collection cannot be empty")
+	}
+	if len(spec.Keys) == 0 {
+		return fmt.Errorf("keys cannot be empty")
+	}
+	for _, key := range spec.Keys {
+		if strings.TrimPrefix(key, "-") == "" {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+	return nil
+}
+
+//keysDoc returns ordered key document of index
+func (spec *IndexSpec) keysDoc() bson.D {
+	keys := bson.D{}
+	for _, key := range spec.Keys {
+		if strings.HasPrefix(key, "-") {
+			keys = append(keys, bson.E{Key: key[1:], Value: -1})
+		} else {
+			keys = append(keys, bson.E{Key: key, Value: 1})
+		}
+	}
+	return keys
+}
+
+//IndexName returns name of index, which is generated in the same way as mongoDB if not specified
+func (spec *IndexSpec) IndexName() string {
+	if spec.Name != "" {
+		return spec.Name
+	}
+	return keysString(spec.keysDoc())
+}
+
+//keysString returns keys in the form of field1_1_field2_-1
+func keysString(keys bson.D) string {
+	items := make([]string, 0, len(keys))
+	for _, e := range keys {
+		items = append(items, fmt.Sprintf("%s_%v", e.Key, e.Value))
+	}
+	return strings.Join(items, "_")
+}
+
+//expectedIndexes returns declared indexes and extra indexes grouped by collection
+func expectedIndexes(extra []*IndexSpec) map[string][]*IndexSpec {
+	indexes := make(map[string][]*IndexSpec)
+	for _, spec := range append(append([]*IndexSpec{}, declaredIndexes...), extra...) {
+		indexes[spec.Collection] = append(indexes[spec.Collection], spec)
+	}
+	return indexes
+}
+
+//EnsureIndexes create declared indexes and extra indexes of tracker database in background, existing indexes are
+//not modified, so an index whose keys or options are changed should be dropped manually
+func EnsureIndexes(ctx context.Context, cli *mongo.Client, extra []*IndexSpec) error {
+	entry := log.WithFields(log.Fields{Function: "EnsureIndexes"})
+	var firstErr error
+	for collection, specs := range expectedIndexes(extra) {
+		models := make([]mongo.IndexModel, 0, len(specs))
+		for _, spec := range specs {
+			//_id index is created with collection
+			if spec.IndexName() == "_id_" {
+				continue
+			}
+			opts := options.Index().SetName(spec.IndexName()).SetBackground(true)
+			if spec.Unique {
+				opts.SetUnique(true)
+			}
+			if spec.Sparse {
+				opts.SetSparse(true)
+			}
+			models = append(models, mongo.IndexModel{Keys: spec.keysDoc(), Options: opts})
+		}
+		if len(models) == 0 {
+			continue
+		}
+		names, err := cli.Database(MinerTrackerDB).Collection(collection).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			entry.WithError(err).Errorf("creating indexes of collection %s", collection)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		entry.Debugf("indexes of collection %s ensured: %s", collection, strings.Join(names, ", "))
+	}
+	return firstErr
+}
+
+//ListIndexes compare existing indexes with declared indexes and extra indexes of each collection in tracker database
+func ListIndexes(ctx context.Context, cli *mongo.Client, extra []*IndexSpec) ([]*IndexStatus, error) {
+	db := cli.Database(MinerTrackerDB)
+	expected := expectedIndexes(extra)
+	collections, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[string]bool)
+	for _, collection := range collections {
+		created[collection] = true
+		if _, ok := expected[collection]; !ok {
+			expected[collection] = nil
+		}
+	}
+	result := make([]*IndexStatus, 0)
+	for collection, specs := range expected {
+		existing := make(map[string]string)
+		//indexes cannot be listed before collection is created
+		if created[collection] {
+			cur, err := db.Collection(collection).Indexes().List(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for cur.Next(ctx) {
+				index := struct {
+					Name string `bson:"name"`
+					Keys bson.D `bson:"key"`
+				}{}
+				err := cur.Decode(&index)
+				if err != nil {
+					cur.Close(ctx)
+					return nil, err
+				}
+				existing[index.Name] = keysString(index.Keys)
+			}
+			cur.Close(ctx)
+		}
+		listed := make(map[string]bool)
+		for _, spec := range specs {
+			name := spec.IndexName()
+			if listed[name] {
+				continue
+			}
+			listed[name] = true
+			status := &IndexStatus{Collection: collection, Name: name, Expected: keysString(spec.keysDoc()), Existing: existing[name]}
+			switch {
+			case status.Existing == "":
+				status.Status = IndexMissing
+			case status.Existing != status.Expected:
+				status.Status = IndexConflict
+			default:
+				status.Status = IndexOK
+			}
+			result = append(result, status)
+		}
+		for name, keys := range existing {
+			if listed[name] {
+				continue
+			}
+			//_id index of every collection is expected
+			if name == "_id_" {
+				result = append(result, &IndexStatus{Collection: collection, Name: name, Expected: keys, Existing: keys, Status: IndexOK})
+			} else {
+				result = append(result, &IndexStatus{Collection: collection, Name: name, Existing: keys, Status: IndexExtra})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Collection != result[j].Collection {
+			return result[i].Collection < result[j].Collection
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package yttracker
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//IngestionLag distribution of lag(millisecond) of one SN, lag of a miner is the time between receiving its report
+//from the fastest SN and from this SN
+type IngestionLag struct {
+	SN           string `json:"sn"`
+	Miners       int    `json:"miners"`
+	LastReceived int64  `json:"lastReceived"`
+	P50          int64  `json:"p50"`
+	P90          int64  `json:"p90"`
+	P99          int64  `json:"p99"`
+	Max          int64  `json:"max"`
+}
+
+//percentile returns the value at p of sorted values
+func percentile(values []int64, p float64) int64 {
+	return values[int(float64(len(values)-1)*p)]
+}
+
+//LagHandler show ingestion lag distribution of each SN
+func (tracker *MinerTracker) LagHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "LagHandler"})
+	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
+	cur, err := collection.Find(context.Background(), bson.M{"receiveTimes": bson.M{"$exists": true}}, options.Find().SetProjection(bson.M{"_id": 1, "receiveTimes": 1}))
+	if err != nil {
+		entry.WithError(err).Error("finding receive times of miners")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	defer cur.Close(context.Background())
+	lags := make(map[string][]int64)
+	last := make(map[string]int64)
+	for cur.Next(context.Background()) {
+		node := new(Node)
+		err := cur.Decode(node)
+		if err != nil {
+			entry.WithError(err).Error("decoding receive times of miner")
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		var latest int64
+		for _, t := range node.ReceiveTimes {
+			if t > latest {
+				latest = t
+			}
+		}
+		for sn, t := range node.ReceiveTimes {
+			lags[sn] = append(lags[sn], latest-t)
+			if t > last[sn] {
+				last[sn] = t
+			}
+		}
+	}
+	result := make([]*IngestionLag, 0, len(lags))
+	for sn, values := range lags {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		result = append(result, &IngestionLag{SN: sn, Miners: len(values), LastReceived: last[sn], P50: percentile(values, 0.5), P90: percentile(values, 0.9), P99: percentile(values, 0.99), Max: values[len(values)-1]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SN < result[j].SN })
+	return c.JSON(http.StatusOK, result)
+}
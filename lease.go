@@ -0,0 +1,194 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//LeaseTab collection name of leader lease
+var LeaseTab = "Lease"
+
+//leaseID ID of the only lease document, which is shared by all tracker instances
+const leaseID = "tracker"
+
+//Lease leader lease shared by all tracker instances, Token is increased each time the lease
+//is taken over by an instance, and is used as fencing token on writes of leader
+type Lease struct {
+	ID       string `bson:"_id" json:"id"`
+	Holder   string `bson:"holder" json:"holder"`
+	Token    int64  `bson:"token" json:"token"`
+	ExpireAt int64  `bson:"expireAt" json:"expireAt"`
+}
+
+//LeaderElector elect leader among tracker instances by lease document in mongoDB
+type LeaderElector struct {
+	mongoCli   *mongo.Client
+	instanceID string
+	ttl        time.Duration
+	lock       sync.RWMutex
+	leader     bool
+	token      int64
+	deadline   time.Time
+}
+
+//DefaultInstanceID default ID of tracker instance in the form of hostname-pid
+func DefaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+//NewLeaderElector create a new leader elector, lease will expire after ttl if not renewed by leader
+func NewLeaderElector(mongoCli *mongo.Client, instanceID string, ttl time.Duration) *LeaderElector {
+	if instanceID == "" {
+		instanceID = DefaultInstanceID()
+	}
+	return &LeaderElector{mongoCli: mongoCli, instanceID: instanceID, ttl: ttl}
+}
+
+//InstanceID ID of current instance
+func (elector *LeaderElector) InstanceID() string {
+	return elector.instanceID
+}
+
+//IsLeader check if current instance is leader
+func (elector *LeaderElector) IsLeader() bool {
+	elector.lock.RLock()
+	defer elector.lock.RUnlock()
+	return elector.leader && time.Now().Before(elector.deadline)
+}
+
+//Token fencing token of current leadership, 0 if current instance is not leader
+func (elector *LeaderElector) Token() int64 {
+	elector.lock.RLock()
+	defer elector.lock.RUnlock()
+	if !elector.leader {
+		return 0
+	}
+	return elector.token
+}
+
+//CurrentLease read lease document from database, nil is returned if no instance has ever been leader
+func (elector *LeaderElector) CurrentLease(ctx context.Context) (*Lease, error) {
+	lease := new(Lease)
+	err := elector.mongoCli.Database(MinerTrackerDB).Collection(LeaseTab).FindOne(ctx, bson.M{"_id": leaseID}).Decode(lease)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+//Run keep acquiring or renewing lease until ctx is done, onElected is called each time current instance becomes leader,
+//context passed to onElected is cancelled when leadership is lost
+func (elector *LeaderElector) Run(ctx context.Context, onElected func(ctx context.Context, token int64)) {
+	entry := log.WithFields(log.Fields{Function: "LeaderElector", InstanceID: elector.instanceID})
+	go func() {
+		var cancel context.CancelFunc
+		for {
+			if elector.isLeaderLocally() {
+				err := elector.renew(ctx)
+				if err != nil {
+					entry.WithError(err).Warn("renewing lease failed")
+				}
+			} else {
+				token, err := elector.acquire(ctx)
+				if err != nil {
+					entry.WithError(err).Warn("acquiring lease failed")
+				} else if token > 0 {
+					entry.Infof("elected as leader, fencing token: %d", token)
+					var lctx context.Context
+					lctx, cancel = context.WithCancel(ctx)
+					onElected(lctx, token)
+				}
+			}
+			if !elector.IsLeader() && cancel != nil {
+				entry.Warn("leadership lost")
+				elector.stepDown()
+				cancel()
+				cancel = nil
+			}
+			if !sleepContext(ctx, elector.ttl/3) {
+				if cancel != nil {
+					cancel()
+				}
+				return
+			}
+		}
+	}()
+}
+
+func (elector *LeaderElector) isLeaderLocally() bool {
+	elector.lock.RLock()
+	defer elector.lock.RUnlock()
+	return elector.leader
+}
+
+func (elector *LeaderElector) stepDown() {
+	elector.lock.Lock()
+	defer elector.lock.Unlock()
+	elector.leader = false
+}
+
+//acquire take over the lease if it is expired or held by current instance, returns new fencing token,
+//or 0 if lease is held by another instance
+func (elector *LeaderElector) acquire(ctx context.Context) (int64, error) {
+	collection := elector.mongoCli.Database(MinerTrackerDB).Collection(LeaseTab)
+	start := time.Now()
+	now := start.UnixNano() / int64(time.Millisecond)
+	expireAt := start.Add(elector.ttl).UnixNano() / int64(time.Millisecond)
+	lease := new(Lease)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": leaseID, "$or": bson.A{bson.M{"expireAt": bson.M{"$lt": now}}, bson.M{"holder": elector.instanceID}}}, bson.M{"$set": bson.M{"holder": elector.instanceID, "expireAt": expireAt}, "$inc": bson.M{"token": 1}}, opts).Decode(lease)
+	if err == mongo.ErrNoDocuments {
+		lease = &Lease{ID: leaseID, Holder: elector.instanceID, Token: 1, ExpireAt: expireAt}
+		_, err = collection.InsertOne(ctx, lease)
+		if isDuplicateKeyError(err) {
+			return 0, nil
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	elector.lock.Lock()
+	defer elector.lock.Unlock()
+	elector.leader = true
+	elector.token = lease.Token
+	elector.deadline = start.Add(elector.ttl)
+	return lease.Token, nil
+}
+
+//renew extend lease held by current instance, leadership is kept until the lease renewed last time expires
+//if renewing fails temporarily
+func (elector *LeaderElector) renew(ctx context.Context) error {
+	collection := elector.mongoCli.Database(MinerTrackerDB).Collection(LeaseTab)
+	elector.lock.RLock()
+	token := elector.token
+	elector.lock.RUnlock()
+	start := time.Now()
+	expireAt := start.Add(elector.ttl).UnixNano() / int64(time.Millisecond)
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": leaseID, "holder": elector.instanceID, "token": token}, bson.M{"$set": bson.M{"expireAt": expireAt}})
+	if err != nil {
+		return err
+	}
+	elector.lock.Lock()
+	defer elector.lock.Unlock()
+	if result.MatchedCount == 0 {
+		elector.leader = false
+		return fmt.Errorf("lease has been taken over by another instance")
+	}
+	elector.deadline = start.Add(elector.ttl)
+	return nil
+}
@@ -0,0 +1,135 @@
+package yttracker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+)
+
+//ComponentHook hook writing entries whose level is enabled for their component, entries without component are
+//filtered by default level. Entries of disabled levels are dropped before formatting, output of logger should be
+//discarded since all entries are written by this hook
+type ComponentHook struct {
+	Formatter  log.Formatter
+	Writer     io.Writer
+	Default    log.Level
+	Components map[string]log.Level
+}
+
+//NewHook create hook of logger writing to writer by format and levels of all components
+func (conf *LogConfig) NewHook(writer io.Writer) (*ComponentHook, error) {
+	hook := &ComponentHook{Writer: writer}
+	switch strings.ToLower(conf.Format) {
+	case "", "text":
+		hook.Formatter = &log.TextFormatter{}
+	case "json":
+		hook.Formatter = &log.JSONFormatter{}
+	default:
+		return nil, fmt.Errorf("no such log format: %s", conf.Format)
+	}
+	level, err := log.ParseLevel(conf.Level)
+	if err != nil {
+		return nil, err
+	}
+	hook.Default = level
+	hook.Components = make(map[string]log.Level)
+	for component, lvl := range conf.Levels {
+		if !isComponent(component) {
+			return nil, fmt.Errorf("no such log component: %s", component)
+		}
+		level, err := log.ParseLevel(lvl)
+		if err != nil {
+			return nil, fmt.Errorf("log level of %s: %s", component, err)
+		}
+		hook.Components[component] = level
+	}
+	return hook, nil
+}
+
+//Enabled check whether level of entry is enabled for its component
+func (hook *ComponentHook) Enabled(entry *log.Entry) bool {
+	level := hook.Default
+	if component, ok := entry.Data[Component].(string); ok {
+		if l, ok := hook.Components[component]; ok {
+			level = l
+		}
+	}
+	return entry.Level <= level
+}
+
+//Levels levels enabled for any component
+func (hook *ComponentHook) Levels() []log.Level {
+	return log.AllLevels[:hook.MaxLevel()+1]
+}
+
+//Fire format and write entry if its level is enabled for its component, hooks are fired with lock of logger held
+//so that writing need not be synchronized
+func (hook *ComponentHook) Fire(entry *log.Entry) error {
+	if !hook.Enabled(entry) {
+		return nil
+	}
+	serialized, err := hook.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = hook.Writer.Write(serialized)
+	return err
+}
+
+//MaxLevel the most verbose level of all components, which should be used as level of logger
+func (hook *ComponentHook) MaxLevel() log.Level {
+	max := hook.Default
+	for _, level := range hook.Components {
+		if level > max {
+			max = level
+		}
+	}
+	return max
+}
+
+//DiscardFormatter formatter of logger whose entries are all written by ComponentHook, formatting is skipped
+//since output of logger is discarded
+type DiscardFormatter struct{}
+
+//Format return nothing
+func (DiscardFormatter) Format(entry *log.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+//components all components whose log level can be set
+var components = []string{ComponentSync, ComponentTracking, ComponentHTTP, ComponentAuth, ComponentAlert}
+
+func isComponent(component string) bool {
+	for _, c := range components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+//requestLogger log HTTP requests as debug entries of http component
+func requestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		req := c.Request()
+		res := c.Response()
+		log.WithFields(log.Fields{
+			Component:  ComponentHTTP,
+			"method":   req.Method,
+			"uri":      req.RequestURI,
+			"status":   res.Status,
+			"remoteIP": c.RealIP(),
+			"latency":  time.Since(start).String(),
+			"bytesOut": res.Size,
+		}).Debug("request handled")
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//SchemaTab collection name of schema version document
+var SchemaTab = "Schema"
+
+//schemaID ID of schema version document of tracker collections
+const schemaID = "tracker"
+
+//SchemaVersion schema version of tracker collections, all migrations with version not greater than Version are applied
+type SchemaVersion struct {
+	ID        string `bson:"_id"`
+	Version   int    `bson:"version"`
+	UpdatedAt int64  `bson:"updatedAt"`
+}
+
+//Migration one step of schema migration, Up must be idempotent since a step interrupted before schema version
+//is saved will run again, and several instances may run it at the same time
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, cli *mongo.Client) error
+}
+
+//migrations all migration steps ordered by version
+var migrations = []*Migration{
+	{Version: 1, Description: "backfill missing stableStat and uspaces of miners", Up: backfillNodeDefaults},
+	{Version: 2, Description: "backfill uspaceTotal of miners", Up: backfillUspaceTotal},
+}
+
+//LatestSchemaVersion returns schema version after all migrations are applied
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+//GetSchemaVersion read current schema version, 0 if no migration has been applied
+func GetSchemaVersion(ctx context.Context, cli *mongo.Client) (int, error) {
+	version := new(SchemaVersion)
+	err := cli.Database(MinerTrackerDB).Collection(SchemaTab).FindOne(ctx, bson.M{"_id": schemaID}).Decode(version)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version.Version, nil
+}
+
+//PendingMigrations returns migrations not applied yet in order of version
+func PendingMigrations(ctx context.Context, cli *mongo.Client) ([]*Migration, error) {
+	current, err := GetSchemaVersion(ctx, cli)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*Migration, 0)
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+//Migrate apply pending migrations with version not greater than target in order, schema version is saved after
+//each step so that interrupted migration continues from the failed step, returns migrations applied
+func Migrate(ctx context.Context, cli *mongo.Client, target int) ([]*Migration, error) {
+	entry := log.WithFields(log.Fields{Function: "Migrate"})
+	current, err := GetSchemaVersion(ctx, cli)
+	if err != nil {
+		entry.WithError(err).Error("reading schema version")
+		return nil, err
+	}
+	if current > LatestSchemaVersion() {
+		entry.Warnf("schema version %d is newer than %d supported by this version of tracker", current, LatestSchemaVersion())
+		return nil, nil
+	}
+	applied := make([]*Migration, 0)
+	for _, m := range migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+		entry.Infof("migrating schema to version %d: %s", m.Version, m.Description)
+		err := m.Up(ctx, cli)
+		if err != nil {
+			entry.WithError(err).Errorf("migrating schema to version %d", m.Version)
+			return applied, fmt.Errorf("migrating schema to version %d: %s", m.Version, err)
+		}
+		//$max keeps version from going backwards when migrations run in several instances
+		_, err = cli.Database(MinerTrackerDB).Collection(SchemaTab).UpdateOne(ctx, bson.M{"_id": schemaID}, bson.M{"$max": bson.M{"version": m.Version}, "$set": bson.M{"updatedAt": time.Now().Unix()}}, options.Update().SetUpsert(true))
+		if err != nil {
+			entry.WithError(err).Errorf("saving schema version %d", m.Version)
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+//backfillNodeDefaults set stableStat and uspaces of miners inserted before these fields were added,
+//stable statistics start from the time of migration
+func backfillNodeDefaults(ctx context.Context, cli *mongo.Client) error {
+	entry := log.WithFields(log.Fields{Function: "backfillNodeDefaults"})
+	collection := cli.Database(MinerTrackerDB).Collection(NodeTab)
+	result, err := collection.UpdateMany(ctx, bson.M{"stableStat": nil}, bson.M{"$set": bson.M{"stableStat": &StableStatistics{StartTime: time.Now().Unix(), Counter: 0, Ratio: 1}}})
+	if err != nil {
+		return err
+	}
+	entry.Infof("stableStat of %d miners is backfilled", result.ModifiedCount)
+	result, err = collection.UpdateMany(ctx, bson.M{"uspaces": nil}, bson.M{"$set": bson.M{"uspaces": bson.M{}}})
+	if err != nil {
+		return err
+	}
+	entry.Infof("uspaces of %d miners is backfilled", result.ModifiedCount)
+	return nil
+}
+
+//backfillUspaceTotal set uspaceTotal of miners to sum of uspaces, miners whose uspaces is changed during migration
+//are skipped since their total is saved by syncNode
+func backfillUspaceTotal(ctx context.Context, cli *mongo.Client) error {
+	entry := log.WithFields(log.Fields{Function: "backfillUspaceTotal"})
+	collection := cli.Database(MinerTrackerDB).Collection(NodeTab)
+	cur, err := collection.Find(ctx, bson.M{"uspaceTotal": bson.M{"$exists": false}}, options.Find().SetProjection(bson.M{"_id": 1, "uspaces": 1}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	count := 0
+	for cur.Next(ctx) {
+		node := new(Node)
+		err := cur.Decode(node)
+		if err != nil {
+			return err
+		}
+		cond := bson.M{"_id": node.ID, "uspaceTotal": bson.M{"$exists": false}, "uspaces": bson.M{"$exists": false}}
+		if uspaces, ok := cur.Current.Lookup("uspaces").DocumentOK(); ok {
+			cond["uspaces"] = uspaces
+		}
+		result, err := collection.UpdateOne(ctx, cond, bson.M{"$set": bson.M{"uspaceTotal": node.UspacesSum()}})
+		if err != nil {
+			return err
+		}
+		count += int(result.ModifiedCount)
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	entry.Infof("uspaceTotal of %d miners is backfilled", count)
+	return nil
+}
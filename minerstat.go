@@ -4,6 +4,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 //state of miner log
@@ -40,14 +42,20 @@ type MinerLogResp struct {
 
 //TrackProgress struct
 type TrackProgress struct {
-	ID        int32 `bson:"_id"`
-	Start     int64 `bson:"start"`
-	Timestamp int64 `bson:"timestamp"`
+	ID        int32  `bson:"_id"`
+	Start     int64  `bson:"start"`
+	Timestamp int64  `bson:"timestamp"`
+	URL       string `bson:"url"`
+	//Token fencing token of leader writing this record last time
+	Token int64 `bson:"token"`
 }
 
+//ErrProgressChanged tracking progress has been changed by another instance, e.g. a newer leader
+var ErrProgressChanged = errors.New("tracking progress has been changed by another instance")
+
 //GetMinerLogs find miner logs
 func GetMinerLogs(httpCli *http.Client, url string, from int64, count int, skipTime int64) (*MinerLogResp, error) {
-	entry := log.WithFields(log.Fields{Function: "GetMinerLogs"})
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "GetMinerLogs"})
 	count++
 	fullURL := fmt.Sprintf("%s/sync/getMinerLogs?start=%d&count=%d", url, from, count)
 	entry.Debugf("fetching miner logs by URL: %s", fullURL)
@@ -99,72 +107,311 @@ func GetMinerLogs(httpCli *http.Client, url string, from int64, count int, skipT
 	return &MinerLogResp{MinerLogs: response, More: false, Next: 0}, nil
 }
 
-//TrackingStat tracking miner logs and process
-func (tracker *MinerTracker) TrackingStat(ctx context.Context) {
-	entry := log.WithFields(log.Fields{Function: "TrackingStat"})
-	urls := tracker.minerStat.AllSyncURLs
-	snCount := len(urls)
-	collectionMiner := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
+//TrackingStat tracking miner logs and process until ctx is done, token is the fencing token of leadership
+//and progress written by leaders with newer token will not be overwritten, alert rules are evaluated and offline
+//miners are detected as well
+func (tracker *MinerTracker) TrackingStat(ctx context.Context, token int64) {
+	tracker.trackLock.Lock()
+	defer tracker.trackLock.Unlock()
+	tracker.trackCtx = ctx
+	tracker.trackToken = token
+	tracker.trackers = make(map[int32]*statWorker)
+	go tracker.alerts.Run(ctx)
+	go tracker.sweepOffline(ctx)
+	go tracker.reconcileUspaces(ctx)
+	for _, ep := range tracker.statConfig().AllSyncURLs {
+		tracker.startTracking(ep)
+	}
+}
+
+//UpdateTracking start tracking workers of added SNs and stop workers of removed SNs,
+//workers of SNs whose URL changed are restarted
+func (tracker *MinerTracker) UpdateTracking(endpoints []*SNEndpoint) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "UpdateTracking"})
+	tracker.trackLock.Lock()
+	defer tracker.trackLock.Unlock()
+	if tracker.trackCtx == nil || tracker.trackCtx.Err() != nil {
+		return
+	}
+	urls := make(map[int32]string)
+	for _, ep := range endpoints {
+		urls[ep.ID] = ep.URL
+	}
+	for snID, worker := range tracker.trackers {
+		if url, ok := urls[snID]; !ok || url != worker.url {
+			worker.cancel()
+			delete(tracker.trackers, snID)
+			entry.WithField(SNID, snID).Infof("stopped tracking SN%d: %s", snID, worker.url)
+		}
+	}
+	for _, ep := range endpoints {
+		if _, ok := tracker.trackers[ep.ID]; !ok {
+			tracker.startTracking(ep)
+		}
+	}
+}
+
+//startTracking must be called with trackLock held
+func (tracker *MinerTracker) startTracking(ep *SNEndpoint) {
+	ctx, cancel := context.WithCancel(tracker.trackCtx)
+	tracker.trackers[ep.ID] = &statWorker{url: ep.URL, cancel: cancel}
+	go tracker.trackSN(ctx, ep.ID, ep.URL, tracker.trackToken)
+}
+
+func (tracker *MinerTracker) trackSN(ctx context.Context, snID int32, url string, token int64) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "TrackingStat", SNID: snID})
 	collectionProgress := tracker.dbCli.Database(MinerTrackerDB).Collection(TrackProgressTab)
-	for i := 0; i < snCount; i++ {
-		snID := int32(i)
-		go func() {
-			entry.Infof("starting tracking SN%d", snID)
-			for {
-				record := new(TrackProgress)
-				err := collectionProgress.FindOne(ctx, bson.M{"_id": snID}).Decode(record)
-				if err != nil {
-					if err == mongo.ErrNoDocuments {
-						record = &TrackProgress{ID: snID, Start: 0, Timestamp: time.Now().Unix()}
-						_, err := collectionProgress.InsertOne(ctx, record)
-						if err != nil {
-							entry.WithError(err).Errorf("insert tracking progress: %d", snID)
-							time.Sleep(time.Duration(tracker.minerStat.WaitTime) * time.Second)
-							continue
-						}
-					} else {
-						entry.WithError(err).Errorf("finding tracking progress: %d", snID)
-						time.Sleep(time.Duration(tracker.minerStat.WaitTime) * time.Second)
-						continue
-					}
-				}
-				minerLogs, err := GetMinerLogs(tracker.httpCli, tracker.minerStat.AllSyncURLs[snID], record.Start, tracker.minerStat.BatchSize, time.Now().Unix()-int64(tracker.minerStat.SkipTime))
+	entry.Infof("starting tracking SN%d", snID)
+	for ctx.Err() == nil {
+		conf := tracker.statConfig()
+		waitTime := time.Duration(conf.WaitTime) * time.Second
+		record := new(TrackProgress)
+		err := collectionProgress.FindOne(ctx, bson.M{"_id": snID}).Decode(record)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				record = &TrackProgress{ID: snID, Start: 0, Timestamp: time.Now().Unix(), URL: url, Token: token}
+				_, err := collectionProgress.InsertOne(ctx, record)
 				if err != nil {
-					time.Sleep(time.Duration(tracker.minerStat.WaitTime) * time.Second)
+					entry.WithError(err).Errorf("insert tracking progress: %d", snID)
+					sleepContext(ctx, waitTime)
 					continue
 				}
-				for _, item := range minerLogs.MinerLogs {
-					if item.Type == NEW && item.FromStatus == -1 {
-						_, err := collectionMiner.InsertOne(ctx, bson.M{"_id": item.MinerID, "status": item.ToStatus, "regtime": item.Timestamp})
-						if err != nil {
-							errstr := err.Error()
-							if strings.ContainsAny(errstr, "duplicate key error") {
-								collectionMiner.UpdateOne(ctx, bson.M{"_id": item.MinerID}, bson.M{"$set": bson.M{"regtime": item.Timestamp}})
-							} else {
-								entry.WithError(err).Errorf("insert new miner %d", item.MinerID)
-							}
-						} else {
-							entry.Infof("new miner %d has been registered", item.MinerID)
-						}
-					} else if item.Type == DELETE && item.ToStatus == -1 {
-						_, err := collectionMiner.DeleteOne(ctx, bson.M{"_id": item.MinerID})
-						if err != nil {
-							entry.WithError(err).Errorf("delete miner %d", item.MinerID)
-						} else {
-							entry.Infof("miner %d has been deleted", item.MinerID)
-						}
-					}
-				}
-				if minerLogs.More {
-					collectionProgress.UpdateOne(ctx, bson.M{"_id": snID}, bson.M{"$set": bson.M{"start": minerLogs.Next, "timestamp": time.Now().Unix()}})
-				} else {
-					if len(minerLogs.MinerLogs) > 0 {
-						next := minerLogs.MinerLogs[len(minerLogs.MinerLogs)-1].ID + 1
-						collectionProgress.UpdateOne(ctx, bson.M{"_id": snID}, bson.M{"$set": bson.M{"start": next, "timestamp": time.Now().Unix()}})
-					}
-					time.Sleep(time.Duration(tracker.minerStat.WaitTime) * time.Second)
-				}
+			} else {
+				entry.WithError(err).Errorf("finding tracking progress: %d", snID)
+				sleepContext(ctx, waitTime)
+				continue
+			}
+		}
+		if record.URL != url {
+			_, err := collectionProgress.UpdateOne(ctx, bson.M{"_id": snID}, bson.M{"$set": bson.M{"url": url}})
+			if err != nil {
+				entry.WithError(err).Warnf("updating URL of tracking progress: %d", snID)
+			}
+		}
+		minerLogs, err := GetMinerLogs(tracker.httpCli, url, record.Start, conf.BatchSize, time.Now().Unix()-int64(conf.SkipTime))
+		if err != nil {
+			sleepContext(ctx, waitTime)
+			continue
+		}
+		next := record.Start
+		if minerLogs.More {
+			next = minerLogs.Next
+		} else if len(minerLogs.MinerLogs) > 0 {
+			next = minerLogs.MinerLogs[len(minerLogs.MinerLogs)-1].ID + 1
+		}
+		if next != record.Start {
+			err = tracker.applyMinerLogs(ctx, snID, token, record.Start, next, minerLogs.MinerLogs)
+			if err != nil {
+				entry.WithError(err).Errorf("applying miner logs of SN%d from %d", snID, record.Start)
+				sleepContext(ctx, waitTime)
+				continue
+			}
+		}
+		if !minerLogs.More {
+			sleepContext(ctx, waitTime)
+		}
+	}
+	entry.Infof("tracking SN%d stopped", snID)
+}
+
+//applyMinerLogs apply one batch of miner logs and advance tracking progress of SN from start to next,
+//both are committed in one transaction when mongoDB runs as a replica set, otherwise each miner log
+//is applied idempotently so that a batch can be replayed safely after crashing before progress advanced,
+//ErrProgressChanged is returned if progress has been advanced by others or written by a leader with newer token
+func (tracker *MinerTracker) applyMinerLogs(ctx context.Context, snID int32, token, start, next int64, minerLogs []*MinerLog) error {
+	collectionMiner := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
+	collectionProgress := tracker.dbCli.Database(MinerTrackerDB).Collection(TrackProgressTab)
+	//transaction may be retried, only logs applied in the committed attempt are alerted
+	var applied []*MinerLog
+	apply := func(sctx context.Context, guard bool) error {
+		applied = applied[:0]
+		for _, item := range minerLogs {
+			ok, err := applyMinerLog(sctx, collectionMiner, item, guard)
+			if err != nil {
+				return err
+			}
+			if ok {
+				applied = append(applied, item)
+			}
+		}
+		result, err := collectionProgress.UpdateOne(sctx, bson.M{"_id": snID, "start": start, "token": bson.M{"$not": bson.M{"$gt": token}}}, bson.M{"$set": bson.M{"start": next, "timestamp": time.Now().Unix(), "token": token}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrProgressChanged
+		}
+		return nil
+	}
+	var err error
+	if !tracker.replicaSet {
+		err = apply(ctx, true)
+	} else {
+		err = tracker.dbCli.UseSession(ctx, func(sctx mongo.SessionContext) error {
+			_, err := sctx.WithTransaction(sctx, func(tctx mongo.SessionContext) (interface{}, error) {
+				return nil, apply(tctx, false)
+			})
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+	for _, item := range applied {
+		if item.Type == NEW {
+			tracker.alerts.MinerRegistered(item.MinerID)
+		} else {
+			tracker.alerts.MinerDeleted(item.MinerID)
+		}
+	}
+	return nil
+}
+
+//applyMinerLog apply one miner log to miner collection, ID of the log is recorded in lastLogID field of miner,
+//when guard is true, logs not newer than lastLogID will be skipped, returns true if miner is registered or deleted
+func applyMinerLog(ctx context.Context, collection *mongo.Collection, item *MinerLog, guard bool) (bool, error) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "applyMinerLog", MinerID: item.MinerID})
+	if item.Type == NEW && item.FromStatus == -1 {
+		cond := bson.M{"_id": item.MinerID}
+		if guard {
+			cond["lastLogID"] = bson.M{"$not": bson.M{"$gte": item.ID}}
+		}
+		result, err := collection.UpdateOne(ctx, cond, bson.M{"$set": bson.M{"regtime": item.Timestamp, "lastLogID": item.ID}, "$setOnInsert": bson.M{"status": item.ToStatus}}, options.Update().SetUpsert(true))
+		if err != nil {
+			if guard && isDuplicateKeyError(err) {
+				entry.Debugf("miner log %d has been applied, skip", item.ID)
+				return false, nil
 			}
-		}()
+			entry.WithError(err).Errorf("insert new miner %d", item.MinerID)
+			return false, err
+		}
+		if result.UpsertedCount > 0 {
+			entry.Infof("new miner %d has been registered", item.MinerID)
+			return true, nil
+		}
+	} else if item.Type == DELETE && item.ToStatus == -1 {
+		cond := bson.M{"_id": item.MinerID}
+		//miner registered again by a newer log must not be deleted when the batch is replayed
+		if guard {
+			cond["lastLogID"] = bson.M{"$not": bson.M{"$gte": item.ID}}
+		}
+		result, err := collection.DeleteOne(ctx, cond)
+		if err != nil {
+			entry.WithError(err).Errorf("delete miner %d", item.MinerID)
+			return false, err
+		}
+		if result.DeletedCount > 0 {
+			entry.Infof("miner %d has been deleted", item.MinerID)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isDuplicateKeyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "duplicate key error")
+}
+
+//isReplicaSet check if mongoDB is running as a member of replica set
+func isReplicaSet(ctx context.Context, cli *mongo.Client) (bool, error) {
+	result := bson.M{}
+	err := cli.Database("admin").RunCommand(ctx, bson.M{"isMaster": 1}).Decode(&result)
+	if err != nil {
+		return false, err
+	}
+	_, ok := result["setName"]
+	return ok, nil
+}
+
+//TrackProgressLegacyTab collection name of legacy tracking progress records copied before migration
+var TrackProgressLegacyTab = "TrackProgressLegacy"
+
+//MigrateTrackProgress migrate tracking progress records keyed by index of sync URL to records keyed by SN ID,
+//legacy records are recognized by absence of url field, and the record of index i is assigned to the i-th endpoint.
+//Since index and SN ID may overlap, legacy records are copied to TrackProgressLegacyTab first, then all records keyed
+//by SN ID are written, and legacy records are deleted only after every one of them exists, so that migration
+//interrupted at any step can be run again without losing progress. Records already migrated are never overwritten,
+//so that it is safe to run in several instances at the same time
+func MigrateTrackProgress(ctx context.Context, cli *mongo.Client, endpoints []*SNEndpoint) error {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "MigrateTrackProgress"})
+	collection := cli.Database(MinerTrackerDB).Collection(TrackProgressTab)
+	collectionLegacy := cli.Database(MinerTrackerDB).Collection(TrackProgressLegacyTab)
+	current, err := findTrackProgress(ctx, collection, bson.M{"url": bson.M{"$exists": false}})
+	if err != nil {
+		entry.WithError(err).Error("finding legacy tracking progress")
+		return err
+	}
+	for _, record := range current {
+		_, err := collectionLegacy.InsertOne(ctx, record)
+		if err != nil && !isDuplicateKeyError(err) {
+			entry.WithError(err).Errorf("copying legacy tracking progress of index %d", record.ID)
+			return err
+		}
+	}
+	//records overwritten by an interrupted migration are only kept in the copy
+	legacy, err := findTrackProgress(ctx, collectionLegacy, bson.M{})
+	if err != nil {
+		entry.WithError(err).Error("finding copy of legacy tracking progress")
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+	targets := make([]int32, 0)
+	for _, record := range legacy {
+		if record.ID < 0 || int(record.ID) >= len(endpoints) {
+			entry.Warnf("no sync URL of index %d, legacy tracking progress is kept: start=%d", record.ID, record.Start)
+			continue
+		}
+		ep := endpoints[record.ID]
+		entry.Infof("migrating tracking progress of index %d to SN%d(%s): start=%d", record.ID, ep.ID, ep.URL, record.Start)
+		//only missing record or legacy record of the same ID is replaced
+		_, err := collection.ReplaceOne(ctx, bson.M{"_id": ep.ID, "url": bson.M{"$exists": false}}, &TrackProgress{ID: ep.ID, Start: record.Start, Timestamp: record.Timestamp, URL: ep.URL}, options.Replace().SetUpsert(true))
+		if err != nil && !isDuplicateKeyError(err) {
+			entry.WithError(err).Errorf("migrating tracking progress of index %d to SN%d", record.ID, ep.ID)
+			return err
+		}
+		targets = append(targets, ep.ID)
+	}
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": targets}, "url": bson.M{"$exists": true}})
+	if err != nil {
+		entry.WithError(err).Error("counting migrated tracking progress")
+		return err
+	}
+	if int(count) != len(targets) {
+		err := fmt.Errorf("%d of %d tracking progress records are migrated", count, len(targets))
+		entry.WithError(err).Error("checking migrated tracking progress")
+		return err
+	}
+	//legacy records of known index which are not overwritten by migrated records
+	_, err = collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$gte": 0, "$lt": len(endpoints)}, "url": bson.M{"$exists": false}})
+	if err != nil {
+		entry.WithError(err).Error("deleting legacy tracking progress")
+		return err
+	}
+	err = collectionLegacy.Drop(ctx)
+	if err != nil {
+		entry.WithError(err).Error("dropping copy of legacy tracking progress")
+		return err
+	}
+	entry.Infof("%d tracking progress records migrated", len(targets))
+	return nil
+}
+
+func findTrackProgress(ctx context.Context, collection *mongo.Collection, filter interface{}) ([]*TrackProgress, error) {
+	cur, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	records := make([]*TrackProgress, 0)
+	for cur.Next(ctx) {
+		record := new(TrackProgress)
+		err := cur.Decode(record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
 	}
+	return records, cur.Err()
 }
@@ -0,0 +1,121 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//testMongoURLEnv environment variable of mongoDB URL used by tests which need a database
+const testMongoURLEnv = "YTTRACKER_TEST_MONGO"
+
+//testCollection returns an empty collection in test database and a function dropping it, tests are skipped if
+//mongoDB URL is not set
+func testCollection(t *testing.T) (*mongo.Collection, func()) {
+	url := os.Getenv(testMongoURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testMongoURLEnv)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cli, err := mongo.Connect(ctx, options.Client().ApplyURI(url))
+	if err != nil {
+		t.Fatalf("connecting mongoDB: %s", err)
+	}
+	collection := cli.Database("yttracker_test").Collection(fmt.Sprintf("%s_%d", t.Name(), time.Now().UnixNano()))
+	return collection, func() {
+		collection.Drop(context.Background())
+		cli.Disconnect(context.Background())
+	}
+}
+
+//minerState fields of miner affected by miner logs and sync
+type minerState struct {
+	ID         int32  `bson:"_id"`
+	RegTime    int64  `bson:"regtime"`
+	Status     int32  `bson:"status"`
+	LastLogID  int64  `bson:"lastLogID"`
+	Owner      string `bson:"owner"`
+	StableStat bson.M `bson:"stableStat"`
+}
+
+func readMiners(t *testing.T, collection *mongo.Collection) map[int32]*minerState {
+	cur, err := collection.Find(context.Background(), bson.M{})
+	if err != nil {
+		t.Fatalf("finding miners: %s", err)
+	}
+	defer cur.Close(context.Background())
+	miners := make(map[int32]*minerState)
+	for cur.Next(context.Background()) {
+		m := new(minerState)
+		if err := cur.Decode(m); err != nil {
+			t.Fatalf("decoding miner: %s", err)
+		}
+		miners[m.ID] = m
+	}
+	return miners
+}
+
+//TestApplyMinerLogReplay interrupt a batch after each miner log, then replay the whole batch as tracker does after
+//crashing before progress is advanced, the final state must be the same as applying the batch once
+func TestApplyMinerLogReplay(t *testing.T) {
+	collection, drop := testCollection(t)
+	defer drop()
+	ctx := context.Background()
+	batch := []*MinerLog{
+		{ID: 10, MinerID: 1, FromStatus: 1, ToStatus: -1, Type: DELETE, Timestamp: 100},
+		{ID: 11, MinerID: 1, FromStatus: -1, ToStatus: 0, Type: NEW, Timestamp: 110},
+		{ID: 12, MinerID: 2, FromStatus: -1, ToStatus: 0, Type: NEW, Timestamp: 120},
+		{ID: 13, MinerID: 3, FromStatus: 1, ToStatus: -1, Type: DELETE, Timestamp: 130},
+	}
+	for interrupted := 0; interrupted <= len(batch); interrupted++ {
+		_, err := collection.DeleteMany(ctx, bson.M{})
+		if err != nil {
+			t.Fatalf("cleaning miners: %s", err)
+		}
+		_, err = collection.InsertMany(ctx, []interface{}{
+			bson.M{"_id": int32(1), "regtime": int64(1), "status": int32(1), "lastLogID": int64(1), "owner": "old"},
+			bson.M{"_id": int32(3), "regtime": int64(3), "status": int32(1), "lastLogID": int64(3), "owner": "deleted"},
+		})
+		if err != nil {
+			t.Fatalf("inserting miners: %s", err)
+		}
+		for _, item := range batch[:interrupted] {
+			if _, err := applyMinerLog(ctx, collection, item, true); err != nil {
+				t.Fatalf("applying miner log %d: %s", item.ID, err)
+			}
+		}
+		//miner registered again is synced by SN before the batch is replayed
+		if interrupted >= 2 {
+			_, err := collection.UpdateOne(ctx, bson.M{"_id": int32(1)}, bson.M{"$set": bson.M{"owner": "new", "stableStat": bson.M{"ratio": 1}}})
+			if err != nil {
+				t.Fatalf("syncing miner: %s", err)
+			}
+		}
+		for _, item := range batch {
+			if _, err := applyMinerLog(ctx, collection, item, true); err != nil {
+				t.Fatalf("replaying miner log %d after interrupted at %d: %s", item.ID, interrupted, err)
+			}
+		}
+		miners := readMiners(t, collection)
+		if len(miners) != 2 {
+			t.Fatalf("interrupted at %d: expected miners 1 and 2, got %d miners", interrupted, len(miners))
+		}
+		m1 := miners[1]
+		if m1 == nil || m1.RegTime != 110 || m1.Status != 0 || m1.LastLogID != 11 {
+			t.Fatalf("interrupted at %d: miner 1 is not registered again: %+v", interrupted, m1)
+		}
+		if interrupted >= 2 && (m1.Owner != "new" || m1.StableStat == nil) {
+			t.Fatalf("interrupted at %d: synced fields of miner 1 are lost: %+v", interrupted, m1)
+		}
+		if m2 := miners[2]; m2 == nil || m2.RegTime != 120 || m2.LastLogID != 12 {
+			t.Fatalf("interrupted at %d: miner 2 is not registered: %+v", interrupted, m2)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package yttracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//nodeMapping mapping of one field of Node, which is declared by struct tags of Node: bson tag is the name of field
+//in database and JSON output unless json tag is "-", pb tag is the name of corresponding field in NodeMsg with options, fields without
+//pb tag are maintained by tracker itself and never exchanged with SN or subscribers, option "merge" of pb tag
+//means the field is merged into stored value by syncNode instead of being overwritten
+type nodeMapping struct {
+	field   string
+	name    string
+	pb      string
+	merge   bool
+	index   int
+	pbIndex int
+}
+
+//nodeConverter converts field of Node whose type differs from NodeMsg
+type nodeConverter struct {
+	toPB   func(node *Node, msg *pb.NodeMsg) error
+	fromPB func(node *Node, msg *pb.NodeMsg) error
+}
+
+//converters of Node fields keyed by field name
+var nodeConverters = map[string]*nodeConverter{
+	"Other": {toPB: otherToExt, fromPB: extToOther},
+	"Ext":   {toPB: extToExtensions, fromPB: extensionsToExt},
+}
+
+//fields of NodeMsg describing the message instead of miner, which are not mapped by Node
+var messageFields = map[string]bool{"Seq": true}
+
+//nodeMappings mappings of all fields of Node in order of declaration
+var nodeMappings []*nodeMapping
+
+//nodeMappingsByName mappings of Node fields keyed by their names in database
+var nodeMappingsByName map[string]*nodeMapping
+
+func init() {
+	nodeMappings, nodeMappingsByName = buildNodeMappings()
+}
+
+//buildNodeMappings read mappings from struct tags of Node, it panics if tags of Node are inconsistent with NodeMsg,
+//so that a field added to one side without the other is found at startup
+func buildNodeMappings() ([]*nodeMapping, map[string]*nodeMapping) {
+	mappings := make([]*nodeMapping, 0)
+	byName := make(map[string]*nodeMapping)
+	nodeType := reflect.TypeOf(Node{})
+	msgType := reflect.TypeOf(pb.NodeMsg{})
+	mapped := make(map[string]bool)
+	for i := 0; i < nodeType.NumField(); i++ {
+		field := nodeType.Field(i)
+		name := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if name == "" {
+			panic(fmt.Sprintf("bson tag of Node.%s is missing", field.Name))
+		}
+		if jsonName := strings.Split(field.Tag.Get("json"), ",")[0]; jsonName != name && jsonName != "-" {
+			panic(fmt.Sprintf("json tag of Node.%s must be the same as bson tag %q", field.Name, name))
+		}
+		m := &nodeMapping{field: field.Name, name: name, index: i, pbIndex: -1}
+		if tag := field.Tag.Get("pb"); tag != "" {
+			opts := strings.Split(tag, ",")
+			m.pb = opts[0]
+			for _, opt := range opts[1:] {
+				switch opt {
+				case "merge":
+					m.merge = true
+				default:
+					panic(fmt.Sprintf("unknown option %q in pb tag of Node.%s", opt, field.Name))
+				}
+			}
+			pbField, ok := msgType.FieldByName(m.pb)
+			if !ok {
+				panic(fmt.Sprintf("NodeMsg.%s mapped by Node.%s does not exist", m.pb, field.Name))
+			}
+			if pbField.Type != field.Type && nodeConverters[field.Name] == nil {
+				panic(fmt.Sprintf("no converter between Node.%s and NodeMsg.%s", field.Name, m.pb))
+			}
+			m.pbIndex = pbField.Index[0]
+			mapped[m.pb] = true
+		}
+		mappings = append(mappings, m)
+		byName[name] = m
+	}
+	for i := 0; i < msgType.NumField(); i++ {
+		field := msgType.Field(i)
+		if field.PkgPath != "" || strings.HasPrefix(field.Name, "XXX_") || messageFields[field.Name] {
+			continue
+		}
+		if !mapped[field.Name] {
+			panic(fmt.Sprintf("NodeMsg.%s is not mapped by any field of Node", field.Name))
+		}
+	}
+	return mappings, byName
+}
+
+//nodeField returns value of miner field by its name in database
+func nodeField(node *Node, name string) (reflect.Value, bool) {
+	m, ok := nodeMappingsByName[name]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(node).Elem().Field(m.index), true
+}
+
+//insertDoc returns document of miner inserted when it is reported for the first time, which contains all fields
+//exchanged with NodeMsg
+func (node *Node) insertDoc() bson.M {
+	doc := bson.M{}
+	v := reflect.ValueOf(node).Elem()
+	for _, m := range nodeMappings {
+		if m.pb != "" {
+			doc[m.name] = v.Field(m.index).Interface()
+		}
+	}
+	return doc
+}
+
+//updateDoc returns fields of miner overwritten when it is reported again, fields merged by syncNode are not included
+func (node *Node) updateDoc() bson.M {
+	doc := bson.M{}
+	v := reflect.ValueOf(node).Elem()
+	for _, m := range nodeMappings {
+		if m.pb != "" && !m.merge && m.name != "_id" {
+			doc[m.name] = v.Field(m.index).Interface()
+		}
+	}
+	return doc
+}
+
+//otherToExt marshal Other into Ext as relaxed extended JSON array, which is plain JSON for numbers, strings and
+//booleans as Ext was before, only values without JSON counterpart such as dates are written in extended form
+func otherToExt(node *Node, msg *pb.NodeMsg) error {
+	msg.Ext = ""
+	if node.Other == nil {
+		return nil
+	}
+	//top level of extended JSON must be a document, so the array is marshaled in a wrapper document
+	b, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: node.Other}}, false, false)
+	if err != nil {
+		return err
+	}
+	wrapper := struct {
+		V json.RawMessage `json:"v"`
+	}{}
+	err = json.Unmarshal(b, &wrapper)
+	if err != nil {
+		return err
+	}
+	msg.Ext = string(wrapper.V)
+	return nil
+}
+
+//extToOther parse Ext in canonical or relaxed extended JSON into Other, value which is not an array is kept
+//as the only element of Other
+func extToOther(node *Node, msg *pb.NodeMsg) error {
+	node.Other = bson.A{}
+	if strings.TrimSpace(msg.Ext) == "" {
+		return nil
+	}
+	wrapper := struct {
+		V interface{} `bson:"v"`
+	}{}
+	err := bson.UnmarshalExtJSON([]byte(`{"v":`+msg.Ext+`}`), false, &wrapper)
+	if err != nil {
+		return err
+	}
+	if other, ok := wrapper.V.(bson.A); ok {
+		node.Other = other
+	} else if wrapper.V != nil {
+		node.Other = bson.A{wrapper.V}
+	}
+	return nil
+}
+
+//extToExtensions encode each extension field of Node as a BSON document {v: value}, so that its type is kept
+func extToExtensions(node *Node, msg *pb.NodeMsg) error {
+	msg.Extensions = nil
+	if len(node.Ext) == 0 {
+		return nil
+	}
+	msg.Extensions = make(map[string][]byte, len(node.Ext))
+	for k, v := range node.Ext {
+		b, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+		if err != nil {
+			return fmt.Errorf("encoding extension %s: %s", k, err)
+		}
+		msg.Extensions[k] = b
+	}
+	return nil
+}
+
+//extensionsToExt decode extension fields of NodeMsg encoded as BSON document {v: value}
+func extensionsToExt(node *Node, msg *pb.NodeMsg) error {
+	node.Ext = nil
+	if len(msg.Extensions) == 0 {
+		return nil
+	}
+	node.Ext = make(bson.M, len(msg.Extensions))
+	for k, b := range msg.Extensions {
+		value := struct {
+			V interface{} `bson:"v"`
+		}{}
+		err := bson.Unmarshal(b, &value)
+		if err != nil {
+			return fmt.Errorf("decoding extension %s: %s", k, err)
+		}
+		node.Ext[k] = value.V
+	}
+	return nil
+}
+
+//validExtKey check whether extension name can be used as field name in database
+func validExtKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "$") && !strings.Contains(key, ".")
+}
@@ -0,0 +1,96 @@
+package yttracker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//testNode returns a miner with every field exchanged with NodeMsg set to a non-zero value
+func testNode() *Node {
+	return &Node{
+		ID:              17,
+		NodeID:          "16Uiu2HAmNodeID",
+		PubKey:          "YTA6PubKey",
+		Owner:           "owneraccount",
+		ProfitAcc:       "profitaccount",
+		PoolID:          "pool1",
+		PoolOwner:       "poolowner",
+		Quota:           1 << 40,
+		Addrs:           []string{"/ip4/127.0.0.1/tcp/9001", "/ip4/10.0.0.1/tcp/9001"},
+		CPU:             35,
+		Memory:          60,
+		Bandwidth:       20,
+		MaxDataSpace:    1 << 36,
+		AssignedSpace:   1 << 35,
+		ProductiveSpace: 1 << 34,
+		UsedSpace:       10240,
+		Uspaces:         map[string]int64{"sn0": 10000, "sn1": 240},
+		Weight:          1.5,
+		Valid:           1,
+		Relay:           1,
+		Status:          1,
+		Timestamp:       1593598279,
+		Version:         120,
+		Rebuilding:      1,
+		RealSpace:       1 << 33,
+		Tx:              4096,
+		Rx:              8192,
+		Other:           bson.A{"abc", int32(3), 2.5, true, bson.D{{Key: "k", Value: int32(7)}}},
+		ManualWeight:    2,
+		Unreadable:      true,
+		HashID:          "hashid",
+		BlCount:         3,
+		Filing:          true,
+		AllocatedSpace:  1 << 32,
+		LastReceived:    1593598279123,
+		LastSource:      2,
+		ReceiveTimes:    map[string]int64{"sn0": 1593598279000, "sn2": 1593598279123},
+		UspaceTotal:     10240,
+		Ext:             bson.M{"region": "east", "disks": int64(12), "ratio": 0.75},
+		//field 100 of varint type with value 1, which is not defined in NodeMsg
+		Unrecognized: []byte{0xa0, 0x06, 0x01},
+	}
+}
+
+func TestConvertFillbyRoundTrip(t *testing.T) {
+	node := testNode()
+	nv := reflect.ValueOf(node).Elem()
+	for _, m := range nodeMappings {
+		if m.pb != "" && nv.Field(m.index).IsZero() {
+			t.Fatalf("Node.%s is mapped to NodeMsg.%s but not set in test node", m.field, m.pb)
+		}
+	}
+	msg, err := node.Convert()
+	if err != nil {
+		t.Fatalf("converting node: %s", err)
+	}
+	//subscribers receive NodeMsg after encoding
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("encoding NodeMsg: %s", err)
+	}
+	decoded := new(pb.NodeMsg)
+	err = proto.Unmarshal(b, decoded)
+	if err != nil {
+		t.Fatalf("decoding NodeMsg: %s", err)
+	}
+	filled := new(Node)
+	err = filled.Fillby(decoded)
+	if err != nil {
+		t.Fatalf("filling node: %s", err)
+	}
+	fv := reflect.ValueOf(filled).Elem()
+	for _, m := range nodeMappings {
+		if m.pb == "" {
+			continue
+		}
+		want, got := nv.Field(m.index).Interface(), fv.Field(m.index).Interface()
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("Node.%s changed by round trip through NodeMsg.%s: want %#v, got %#v", m.field, m.pb, want, got)
+		}
+	}
+}
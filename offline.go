@@ -0,0 +1,132 @@
+package yttracker
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//OfflineStat count of all miners and offline miners of one pool
+type OfflineStat struct {
+	PoolID  string `bson:"_id" json:"poolID"`
+	Total   int64  `bson:"total" json:"total"`
+	Offline int64  `bson:"offline" json:"offline"`
+}
+
+func (tracker *MinerTracker) offlineConfig() *OfflineConfig {
+	tracker.configLock.RLock()
+	defer tracker.configLock.RUnlock()
+	return tracker.offline
+}
+
+//sweepOffline mark miners without report for a while as offline periodically until ctx is done,
+//it should be called when current instance becomes leader
+func (tracker *MinerTracker) sweepOffline(ctx context.Context) {
+	for {
+		conf := tracker.offlineConfig()
+		tracker.markOffline(ctx, int64(conf.Threshold))
+		if !sleepContext(ctx, time.Duration(conf.Interval)*time.Second) {
+			return
+		}
+	}
+}
+
+//markOffline set offlineSince field of miners whose last report is older than threshold(seconds),
+//and publish state change of each miner
+func (tracker *MinerTracker) markOffline(ctx context.Context, threshold int64) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "markOffline"})
+	now := time.Now().Unix()
+	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
+	cond := bson.M{"timestamp": bson.M{"$lt": now - threshold}, "offlineSince": bson.M{"$not": bson.M{"$gt": 0}}}
+	cur, err := collection.Find(ctx, cond, options.Find().SetProjection(bson.M{"_id": 1, "poolID": 1, "timestamp": 1}))
+	if err != nil {
+		entry.WithError(err).Error("finding offline miners")
+		return
+	}
+	defer cur.Close(ctx)
+	count := 0
+	for cur.Next(ctx) {
+		node := new(Node)
+		err := cur.Decode(node)
+		if err != nil {
+			entry.WithError(err).Error("decoding offline miner")
+			return
+		}
+		//miner may report again after being found
+		result, err := collection.UpdateOne(ctx, bson.M{"_id": node.ID, "timestamp": cond["timestamp"], "offlineSince": cond["offlineSince"]}, bson.M{"$set": bson.M{"offlineSince": now}})
+		if err != nil {
+			entry.WithError(err).WithField(MinerID, node.ID).Errorf("marking miner %d offline", node.ID)
+			continue
+		}
+		if result.ModifiedCount > 0 {
+			count++
+			tracker.syncSvc.publishState(&pb.MinerStateMsg{ID: node.ID, PoolID: node.PoolID, Offline: true, OfflineSince: now, Timestamp: node.Timestamp})
+		}
+	}
+	if count > 0 {
+		entry.Infof("%d miners are offline", count)
+	}
+}
+
+//clearOffline clear offlineSince field of miner when it reports again, and publish its state change
+func (s *Service) clearOffline(node *Node) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "clearOffline", MinerID: node.ID})
+	collection := s.mongoCli.Database(MinerTrackerDB).Collection(NodeTab)
+	result, err := collection.UpdateOne(context.Background(), bson.M{"_id": node.ID, "offlineSince": bson.M{"$gt": 0}}, bson.M{"$set": bson.M{"offlineSince": int64(0)}})
+	if err != nil {
+		entry.WithError(err).Warnf("marking miner %d online", node.ID)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		entry.Infof("miner %d is online again", node.ID)
+		s.publishState(&pb.MinerStateMsg{ID: node.ID, PoolID: node.PoolID, Offline: false, Timestamp: node.Timestamp})
+	}
+}
+
+//publishState publish state change of miner to state topic
+func (s *Service) publishState(m *pb.MinerStateMsg) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "publishState", MinerID: m.ID})
+	b, err := proto.Marshal(m)
+	if err != nil {
+		entry.WithError(err).Error("encoding MinerStateMsg")
+		return
+	}
+	s.client.Publish(s.stateTopic, b)
+}
+
+//OfflineHandler count all miners and offline miners of each pool, or of the pool specified by poolID
+func (tracker *MinerTracker) OfflineHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "OfflineHandler"})
+	pipeline := make([]bson.M, 0)
+	if poolID := c.QueryParam("poolID"); poolID != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"poolID": poolID}})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": "$poolID", "total": bson.M{"$sum": 1}, "offline": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$offlineSince", 0}}, 1, 0}}}}},
+		bson.M{"$sort": bson.M{"_id": 1}})
+	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
+	cur, err := collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		entry.WithError(err).Error("counting offline miners")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	defer cur.Close(context.Background())
+	stats := make([]*OfflineStat, 0)
+	for cur.Next(context.Background()) {
+		stat := new(OfflineStat)
+		err := cur.Decode(stat)
+		if err != nil {
+			entry.WithError(err).Error("decoding offline statistics")
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		stats = append(stats, stat)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
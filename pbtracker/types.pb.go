@@ -22,43 +22,49 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 // Node message
 type NodeMsg struct {
-	ID                   int32            `protobuf:"varint,1,opt,name=iD,proto3" json:"iD,omitempty"`
-	NodeID               string           `protobuf:"bytes,2,opt,name=nodeID,proto3" json:"nodeID,omitempty"`
-	PubKey               string           `protobuf:"bytes,3,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
-	Owner                string           `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
-	ProfitAcc            string           `protobuf:"bytes,5,opt,name=profitAcc,proto3" json:"profitAcc,omitempty"`
-	PoolID               string           `protobuf:"bytes,6,opt,name=poolID,proto3" json:"poolID,omitempty"`
-	PoolOwner            string           `protobuf:"bytes,7,opt,name=poolOwner,proto3" json:"poolOwner,omitempty"`
-	Quota                int64            `protobuf:"varint,8,opt,name=quota,proto3" json:"quota,omitempty"`
-	Addrs                []string         `protobuf:"bytes,9,rep,name=addrs,proto3" json:"addrs,omitempty"`
-	CPU                  int32            `protobuf:"varint,10,opt,name=cPU,proto3" json:"cPU,omitempty"`
-	Memory               int32            `protobuf:"varint,11,opt,name=memory,proto3" json:"memory,omitempty"`
-	Bandwidth            int32            `protobuf:"varint,12,opt,name=bandwidth,proto3" json:"bandwidth,omitempty"`
-	MaxDataSpace         int64            `protobuf:"varint,13,opt,name=maxDataSpace,proto3" json:"maxDataSpace,omitempty"`
-	AssignedSpace        int64            `protobuf:"varint,14,opt,name=assignedSpace,proto3" json:"assignedSpace,omitempty"`
-	ProductiveSpace      int64            `protobuf:"varint,15,opt,name=productiveSpace,proto3" json:"productiveSpace,omitempty"`
-	UsedSpace            int64            `protobuf:"varint,16,opt,name=usedSpace,proto3" json:"usedSpace,omitempty"`
-	Weight               float64          `protobuf:"fixed64,17,opt,name=weight,proto3" json:"weight,omitempty"`
-	Valid                int32            `protobuf:"varint,18,opt,name=valid,proto3" json:"valid,omitempty"`
-	Relay                int32            `protobuf:"varint,19,opt,name=relay,proto3" json:"relay,omitempty"`
-	Status               int32            `protobuf:"varint,20,opt,name=status,proto3" json:"status,omitempty"`
-	Timestamp            int64            `protobuf:"varint,21,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Version              int32            `protobuf:"varint,22,opt,name=version,proto3" json:"version,omitempty"`
-	Rebuilding           int32            `protobuf:"varint,23,opt,name=rebuilding,proto3" json:"rebuilding,omitempty"`
-	RealSpace            int64            `protobuf:"varint,24,opt,name=realSpace,proto3" json:"realSpace,omitempty"`
-	Tx                   int64            `protobuf:"varint,25,opt,name=tx,proto3" json:"tx,omitempty"`
-	Rx                   int64            `protobuf:"varint,26,opt,name=rx,proto3" json:"rx,omitempty"`
-	Ext                  string           `protobuf:"bytes,27,opt,name=ext,proto3" json:"ext,omitempty"`
-	Uspaces              map[string]int64 `protobuf:"bytes,28,rep,name=uspaces,proto3" json:"uspaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
-	ManualWeight         int32            `protobuf:"varint,29,opt,name=manualWeight,proto3" json:"manualWeight,omitempty"`
-	Unreadable           bool             `protobuf:"varint,30,opt,name=unreadable,proto3" json:"unreadable,omitempty"`
-	Hash                 string           `protobuf:"bytes,31,opt,name=hash,proto3" json:"hash,omitempty"`
-	BlCount              int32            `protobuf:"varint,32,opt,name=blCount,proto3" json:"blCount,omitempty"`
-	Filing               bool             `protobuf:"varint,33,opt,name=filing,proto3" json:"filing,omitempty"`
-	AllocatedSpace       int64            `protobuf:"varint,34,opt,name=allocatedSpace,proto3" json:"allocatedSpace,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	ID                   int32             `protobuf:"varint,1,opt,name=iD,proto3" json:"iD,omitempty"`
+	NodeID               string            `protobuf:"bytes,2,opt,name=nodeID,proto3" json:"nodeID,omitempty"`
+	PubKey               string            `protobuf:"bytes,3,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Owner                string            `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	ProfitAcc            string            `protobuf:"bytes,5,opt,name=profitAcc,proto3" json:"profitAcc,omitempty"`
+	PoolID               string            `protobuf:"bytes,6,opt,name=poolID,proto3" json:"poolID,omitempty"`
+	PoolOwner            string            `protobuf:"bytes,7,opt,name=poolOwner,proto3" json:"poolOwner,omitempty"`
+	Quota                int64             `protobuf:"varint,8,opt,name=quota,proto3" json:"quota,omitempty"`
+	Addrs                []string          `protobuf:"bytes,9,rep,name=addrs,proto3" json:"addrs,omitempty"`
+	CPU                  int32             `protobuf:"varint,10,opt,name=cPU,proto3" json:"cPU,omitempty"`
+	Memory               int32             `protobuf:"varint,11,opt,name=memory,proto3" json:"memory,omitempty"`
+	Bandwidth            int32             `protobuf:"varint,12,opt,name=bandwidth,proto3" json:"bandwidth,omitempty"`
+	MaxDataSpace         int64             `protobuf:"varint,13,opt,name=maxDataSpace,proto3" json:"maxDataSpace,omitempty"`
+	AssignedSpace        int64             `protobuf:"varint,14,opt,name=assignedSpace,proto3" json:"assignedSpace,omitempty"`
+	ProductiveSpace      int64             `protobuf:"varint,15,opt,name=productiveSpace,proto3" json:"productiveSpace,omitempty"`
+	UsedSpace            int64             `protobuf:"varint,16,opt,name=usedSpace,proto3" json:"usedSpace,omitempty"`
+	Weight               float64           `protobuf:"fixed64,17,opt,name=weight,proto3" json:"weight,omitempty"`
+	Valid                int32             `protobuf:"varint,18,opt,name=valid,proto3" json:"valid,omitempty"`
+	Relay                int32             `protobuf:"varint,19,opt,name=relay,proto3" json:"relay,omitempty"`
+	Status               int32             `protobuf:"varint,20,opt,name=status,proto3" json:"status,omitempty"`
+	Timestamp            int64             `protobuf:"varint,21,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Version              int32             `protobuf:"varint,22,opt,name=version,proto3" json:"version,omitempty"`
+	Rebuilding           int32             `protobuf:"varint,23,opt,name=rebuilding,proto3" json:"rebuilding,omitempty"`
+	RealSpace            int64             `protobuf:"varint,24,opt,name=realSpace,proto3" json:"realSpace,omitempty"`
+	Tx                   int64             `protobuf:"varint,25,opt,name=tx,proto3" json:"tx,omitempty"`
+	Rx                   int64             `protobuf:"varint,26,opt,name=rx,proto3" json:"rx,omitempty"`
+	Ext                  string            `protobuf:"bytes,27,opt,name=ext,proto3" json:"ext,omitempty"`
+	Uspaces              map[string]int64  `protobuf:"bytes,28,rep,name=uspaces,proto3" json:"uspaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ManualWeight         int32             `protobuf:"varint,29,opt,name=manualWeight,proto3" json:"manualWeight,omitempty"`
+	Unreadable           bool              `protobuf:"varint,30,opt,name=unreadable,proto3" json:"unreadable,omitempty"`
+	Hash                 string            `protobuf:"bytes,31,opt,name=hash,proto3" json:"hash,omitempty"`
+	BlCount              int32             `protobuf:"varint,32,opt,name=blCount,proto3" json:"blCount,omitempty"`
+	Filing               bool              `protobuf:"varint,33,opt,name=filing,proto3" json:"filing,omitempty"`
+	AllocatedSpace       int64             `protobuf:"varint,34,opt,name=allocatedSpace,proto3" json:"allocatedSpace,omitempty"`
+	LastReceived         int64             `protobuf:"varint,35,opt,name=lastReceived,proto3" json:"lastReceived,omitempty"`
+	LastSource           int32             `protobuf:"varint,36,opt,name=lastSource,proto3" json:"lastSource,omitempty"`
+	ReceiveTimes         map[string]int64  `protobuf:"bytes,37,rep,name=receiveTimes,proto3" json:"receiveTimes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	UspaceTotal          int64             `protobuf:"varint,38,opt,name=uspaceTotal,proto3" json:"uspaceTotal,omitempty"`
+	Extensions           map[string][]byte `protobuf:"bytes,39,rep,name=extensions,proto3" json:"extensions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Seq                  int64             `protobuf:"varint,40,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *NodeMsg) Reset()         { *m = NodeMsg{} }
@@ -324,6 +330,48 @@ func (m *NodeMsg) GetAllocatedSpace() int64 {
 	return 0
 }
 
+func (m *NodeMsg) GetLastReceived() int64 {
+	if m != nil {
+		return m.LastReceived
+	}
+	return 0
+}
+
+func (m *NodeMsg) GetLastSource() int32 {
+	if m != nil {
+		return m.LastSource
+	}
+	return 0
+}
+
+func (m *NodeMsg) GetReceiveTimes() map[string]int64 {
+	if m != nil {
+		return m.ReceiveTimes
+	}
+	return nil
+}
+
+func (m *NodeMsg) GetUspaceTotal() int64 {
+	if m != nil {
+		return m.UspaceTotal
+	}
+	return 0
+}
+
+func (m *NodeMsg) GetExtensions() map[string][]byte {
+	if m != nil {
+		return m.Extensions
+	}
+	return nil
+}
+
+func (m *NodeMsg) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
 type SignMessage struct {
 	AccountName          string   `protobuf:"bytes,1,opt,name=accountName,proto3" json:"accountName,omitempty"`
 	Data                 []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
@@ -379,52 +427,578 @@ func (m *SignMessage) GetSignature() string {
 	return ""
 }
 
+// envelope of NodeMsg relayed between tracker instances
+type RelayMsg struct {
+	Origin               string   `protobuf:"bytes,1,opt,name=origin,proto3" json:"origin,omitempty"`
+	Hops                 int32    `protobuf:"varint,2,opt,name=hops,proto3" json:"hops,omitempty"`
+	Content              []byte   `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Seq                  int64    `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RelayMsg) Reset()         { *m = RelayMsg{} }
+func (m *RelayMsg) String() string { return proto.CompactTextString(m) }
+func (*RelayMsg) ProtoMessage()    {}
+func (*RelayMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{2}
+}
+
+func (m *RelayMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RelayMsg.Unmarshal(m, b)
+}
+func (m *RelayMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RelayMsg.Marshal(b, m, deterministic)
+}
+func (m *RelayMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RelayMsg.Merge(m, src)
+}
+func (m *RelayMsg) XXX_Size() int {
+	return xxx_messageInfo_RelayMsg.Size(m)
+}
+func (m *RelayMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_RelayMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RelayMsg proto.InternalMessageInfo
+
+func (m *RelayMsg) GetOrigin() string {
+	if m != nil {
+		return m.Origin
+	}
+	return ""
+}
+
+func (m *RelayMsg) GetHops() int32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+func (m *RelayMsg) GetContent() []byte {
+	if m != nil {
+		return m.Content
+	}
+	return nil
+}
+
+func (m *RelayMsg) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+// request of snapshot of all miners, sent to snapshot topic or wrapped in RequestMsg
+type SnapshotReq struct {
+	RequestID            string   `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	Filter               string   `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotReq) Reset()         { *m = SnapshotReq{} }
+func (m *SnapshotReq) String() string { return proto.CompactTextString(m) }
+func (*SnapshotReq) ProtoMessage()    {}
+func (*SnapshotReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{3}
+}
+
+func (m *SnapshotReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotReq.Unmarshal(m, b)
+}
+func (m *SnapshotReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotReq.Marshal(b, m, deterministic)
+}
+func (m *SnapshotReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotReq.Merge(m, src)
+}
+func (m *SnapshotReq) XXX_Size() int {
+	return xxx_messageInfo_SnapshotReq.Size(m)
+}
+func (m *SnapshotReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotReq proto.InternalMessageInfo
+
+func (m *SnapshotReq) GetRequestID() string {
+	if m != nil {
+		return m.RequestID
+	}
+	return ""
+}
+
+func (m *SnapshotReq) GetFilter() string {
+	if m != nil {
+		return m.Filter
+	}
+	return ""
+}
+
+// one batch of snapshot sent to requester
+type SnapshotMsg struct {
+	RequestID            string     `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	Nodes                []*NodeMsg `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	End                  bool       `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	Total                int64      `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	Error                string     `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Seq                  int64      `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *SnapshotMsg) Reset()         { *m = SnapshotMsg{} }
+func (m *SnapshotMsg) String() string { return proto.CompactTextString(m) }
+func (*SnapshotMsg) ProtoMessage()    {}
+func (*SnapshotMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{4}
+}
+
+func (m *SnapshotMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotMsg.Unmarshal(m, b)
+}
+func (m *SnapshotMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotMsg.Marshal(b, m, deterministic)
+}
+func (m *SnapshotMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotMsg.Merge(m, src)
+}
+func (m *SnapshotMsg) XXX_Size() int {
+	return xxx_messageInfo_SnapshotMsg.Size(m)
+}
+func (m *SnapshotMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotMsg proto.InternalMessageInfo
+
+func (m *SnapshotMsg) GetRequestID() string {
+	if m != nil {
+		return m.RequestID
+	}
+	return ""
+}
+
+func (m *SnapshotMsg) GetNodes() []*NodeMsg {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *SnapshotMsg) GetEnd() bool {
+	if m != nil {
+		return m.End
+	}
+	return false
+}
+
+func (m *SnapshotMsg) GetTotal() int64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+func (m *SnapshotMsg) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *SnapshotMsg) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+// miner update with sequence number, published to replay topic
+type SeqMsg struct {
+	Seq                  int64    `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Node                 *NodeMsg `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SeqMsg) Reset()         { *m = SeqMsg{} }
+func (m *SeqMsg) String() string { return proto.CompactTextString(m) }
+func (*SeqMsg) ProtoMessage()    {}
+func (*SeqMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{5}
+}
+
+func (m *SeqMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SeqMsg.Unmarshal(m, b)
+}
+func (m *SeqMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SeqMsg.Marshal(b, m, deterministic)
+}
+func (m *SeqMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeqMsg.Merge(m, src)
+}
+func (m *SeqMsg) XXX_Size() int {
+	return xxx_messageInfo_SeqMsg.Size(m)
+}
+func (m *SeqMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeqMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeqMsg proto.InternalMessageInfo
+
+func (m *SeqMsg) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *SeqMsg) GetNode() *NodeMsg {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+// request of updates missed since a sequence number, sent to resume topic or wrapped in RequestMsg
+type ResumeReq struct {
+	RequestID            string   `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResumeReq) Reset()         { *m = ResumeReq{} }
+func (m *ResumeReq) String() string { return proto.CompactTextString(m) }
+func (*ResumeReq) ProtoMessage()    {}
+func (*ResumeReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{6}
+}
+
+func (m *ResumeReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResumeReq.Unmarshal(m, b)
+}
+func (m *ResumeReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResumeReq.Marshal(b, m, deterministic)
+}
+func (m *ResumeReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeReq.Merge(m, src)
+}
+func (m *ResumeReq) XXX_Size() int {
+	return xxx_messageInfo_ResumeReq.Size(m)
+}
+func (m *ResumeReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeReq proto.InternalMessageInfo
+
+func (m *ResumeReq) GetRequestID() string {
+	if m != nil {
+		return m.RequestID
+	}
+	return ""
+}
+
+func (m *ResumeReq) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+// one batch of missed updates sent to requester
+type ResumeMsg struct {
+	RequestID            string    `protobuf:"bytes,1,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	Updates              []*SeqMsg `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	End                  bool      `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	LastSeq              int64     `protobuf:"varint,4,opt,name=lastSeq,proto3" json:"lastSeq,omitempty"`
+	ResyncRequired       bool      `protobuf:"varint,5,opt,name=resyncRequired,proto3" json:"resyncRequired,omitempty"`
+	Error                string    `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ResumeMsg) Reset()         { *m = ResumeMsg{} }
+func (m *ResumeMsg) String() string { return proto.CompactTextString(m) }
+func (*ResumeMsg) ProtoMessage()    {}
+func (*ResumeMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{7}
+}
+
+func (m *ResumeMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResumeMsg.Unmarshal(m, b)
+}
+func (m *ResumeMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResumeMsg.Marshal(b, m, deterministic)
+}
+func (m *ResumeMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeMsg.Merge(m, src)
+}
+func (m *ResumeMsg) XXX_Size() int {
+	return xxx_messageInfo_ResumeMsg.Size(m)
+}
+func (m *ResumeMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeMsg proto.InternalMessageInfo
+
+func (m *ResumeMsg) GetRequestID() string {
+	if m != nil {
+		return m.RequestID
+	}
+	return ""
+}
+
+func (m *ResumeMsg) GetUpdates() []*SeqMsg {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+func (m *ResumeMsg) GetEnd() bool {
+	if m != nil {
+		return m.End
+	}
+	return false
+}
+
+func (m *ResumeMsg) GetLastSeq() int64 {
+	if m != nil {
+		return m.LastSeq
+	}
+	return 0
+}
+
+func (m *ResumeMsg) GetResyncRequired() bool {
+	if m != nil {
+		return m.ResyncRequired
+	}
+	return false
+}
+
+func (m *ResumeMsg) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// request sent to client ID of tracker by point-to-point message, only one of the requests is set
+type RequestMsg struct {
+	Snapshot             *SnapshotReq `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Resume               *ResumeReq   `protobuf:"bytes,2,opt,name=resume,proto3" json:"resume,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RequestMsg) Reset()         { *m = RequestMsg{} }
+func (m *RequestMsg) String() string { return proto.CompactTextString(m) }
+func (*RequestMsg) ProtoMessage()    {}
+func (*RequestMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{8}
+}
+
+func (m *RequestMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestMsg.Unmarshal(m, b)
+}
+func (m *RequestMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestMsg.Marshal(b, m, deterministic)
+}
+func (m *RequestMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestMsg.Merge(m, src)
+}
+func (m *RequestMsg) XXX_Size() int {
+	return xxx_messageInfo_RequestMsg.Size(m)
+}
+func (m *RequestMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestMsg proto.InternalMessageInfo
+
+func (m *RequestMsg) GetSnapshot() *SnapshotReq {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+func (m *RequestMsg) GetResume() *ResumeReq {
+	if m != nil {
+		return m.Resume
+	}
+	return nil
+}
+
+// state change of miner detected by tracker, published to state topic
+type MinerStateMsg struct {
+	ID                   int32    `protobuf:"varint,1,opt,name=iD,proto3" json:"iD,omitempty"`
+	PoolID               string   `protobuf:"bytes,2,opt,name=poolID,proto3" json:"poolID,omitempty"`
+	Offline              bool     `protobuf:"varint,3,opt,name=offline,proto3" json:"offline,omitempty"`
+	OfflineSince         int64    `protobuf:"varint,4,opt,name=offlineSince,proto3" json:"offlineSince,omitempty"`
+	Timestamp            int64    `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MinerStateMsg) Reset()         { *m = MinerStateMsg{} }
+func (m *MinerStateMsg) String() string { return proto.CompactTextString(m) }
+func (*MinerStateMsg) ProtoMessage()    {}
+func (*MinerStateMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{9}
+}
+
+func (m *MinerStateMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MinerStateMsg.Unmarshal(m, b)
+}
+func (m *MinerStateMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MinerStateMsg.Marshal(b, m, deterministic)
+}
+func (m *MinerStateMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MinerStateMsg.Merge(m, src)
+}
+func (m *MinerStateMsg) XXX_Size() int {
+	return xxx_messageInfo_MinerStateMsg.Size(m)
+}
+func (m *MinerStateMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_MinerStateMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MinerStateMsg proto.InternalMessageInfo
+
+func (m *MinerStateMsg) GetID() int32 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *MinerStateMsg) GetPoolID() string {
+	if m != nil {
+		return m.PoolID
+	}
+	return ""
+}
+
+func (m *MinerStateMsg) GetOffline() bool {
+	if m != nil {
+		return m.Offline
+	}
+	return false
+}
+
+func (m *MinerStateMsg) GetOfflineSince() int64 {
+	if m != nil {
+		return m.OfflineSince
+	}
+	return 0
+}
+
+func (m *MinerStateMsg) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*NodeMsg)(nil), "pbtracker.NodeMsg")
+	proto.RegisterMapType((map[string][]byte)(nil), "pbtracker.NodeMsg.ExtensionsEntry")
+	proto.RegisterMapType((map[string]int64)(nil), "pbtracker.NodeMsg.ReceiveTimesEntry")
 	proto.RegisterMapType((map[string]int64)(nil), "pbtracker.NodeMsg.UspacesEntry")
 	proto.RegisterType((*SignMessage)(nil), "pbtracker.SignMessage")
+	proto.RegisterType((*RelayMsg)(nil), "pbtracker.RelayMsg")
+	proto.RegisterType((*SnapshotReq)(nil), "pbtracker.SnapshotReq")
+	proto.RegisterType((*SnapshotMsg)(nil), "pbtracker.SnapshotMsg")
+	proto.RegisterType((*SeqMsg)(nil), "pbtracker.SeqMsg")
+	proto.RegisterType((*ResumeReq)(nil), "pbtracker.ResumeReq")
+	proto.RegisterType((*ResumeMsg)(nil), "pbtracker.ResumeMsg")
+	proto.RegisterType((*RequestMsg)(nil), "pbtracker.RequestMsg")
+	proto.RegisterType((*MinerStateMsg)(nil), "pbtracker.MinerStateMsg")
 }
 
 func init() { proto.RegisterFile("types.proto", fileDescriptor_d938547f84707355) }
 
 var fileDescriptor_d938547f84707355 = []byte{
-	// 607 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x94, 0xcf, 0x53, 0xdb, 0x3a,
-	0x10, 0xc7, 0xc7, 0x09, 0x10, 0xa2, 0x84, 0x1f, 0x4f, 0x8f, 0xc7, 0xdb, 0x52, 0x0a, 0x6e, 0xa6,
-	0xd3, 0xf1, 0x29, 0x87, 0xf6, 0xd2, 0x72, 0xeb, 0x34, 0x3d, 0x30, 0x1d, 0x68, 0xc7, 0x0c, 0xd3,
-	0xf3, 0xda, 0x16, 0x89, 0x06, 0xc7, 0x72, 0x25, 0x19, 0x92, 0x7f, 0xb8, 0x7f, 0x47, 0x67, 0x57,
-	0x0e, 0x09, 0xdc, 0xf4, 0xfd, 0x78, 0xf5, 0x5d, 0xed, 0x6a, 0x65, 0x31, 0xf0, 0xcb, 0x5a, 0xb9,
-	0x71, 0x6d, 0x8d, 0x37, 0xb2, 0x5f, 0x67, 0xde, 0x62, 0x7e, 0xaf, 0xec, 0xe8, 0x4f, 0x4f, 0xf4,
-	0xae, 0x4d, 0xa1, 0xae, 0xdc, 0x54, 0xee, 0x8b, 0x8e, 0x9e, 0x40, 0x14, 0x47, 0xc9, 0x76, 0xda,
-	0xd1, 0x13, 0x79, 0x2c, 0x76, 0x2a, 0x53, 0xa8, 0xcb, 0x09, 0x74, 0xe2, 0x28, 0xe9, 0xa7, 0xad,
-	0x22, 0x5e, 0x37, 0xd9, 0x77, 0xb5, 0x84, 0x6e, 0xe0, 0x41, 0xc9, 0x23, 0xb1, 0x6d, 0x1e, 0x2b,
-	0x65, 0x61, 0x8b, 0x71, 0x10, 0xf2, 0x54, 0xf4, 0x6b, 0x6b, 0xee, 0xb4, 0xff, 0x92, 0xe7, 0xb0,
-	0xcd, 0x5f, 0xd6, 0x80, 0xbd, 0x8c, 0x29, 0x2f, 0x27, 0xb0, 0xd3, 0x7a, 0xb1, 0xe2, 0x5d, 0xc6,
-	0x94, 0x3f, 0xd8, 0xaf, 0xd7, 0xee, 0x5a, 0x01, 0xca, 0xf4, 0xbb, 0x31, 0x1e, 0x61, 0x37, 0x8e,
-	0x92, 0x6e, 0x1a, 0x04, 0x51, 0x2c, 0x0a, 0xeb, 0xa0, 0x1f, 0x77, 0x29, 0x3f, 0x0b, 0x79, 0x28,
-	0xba, 0xf9, 0xcf, 0x5b, 0x10, 0x5c, 0x16, 0x2d, 0x29, 0xe7, 0x5c, 0xcd, 0x8d, 0x5d, 0xc2, 0x80,
-	0x61, 0xab, 0x28, 0x67, 0x86, 0x55, 0xf1, 0xa8, 0x0b, 0x3f, 0x83, 0x21, 0x7f, 0x5a, 0x03, 0x39,
-	0x12, 0xc3, 0x39, 0x2e, 0x26, 0xe8, 0xf1, 0xa6, 0xc6, 0x5c, 0xc1, 0x1e, 0xa7, 0x7e, 0xc6, 0xe4,
-	0x3b, 0xb1, 0x87, 0xce, 0xe9, 0x69, 0xa5, 0x8a, 0x10, 0xb4, 0xcf, 0x41, 0xcf, 0xa1, 0x4c, 0xc4,
-	0x41, 0x6d, 0x4d, 0xd1, 0xe4, 0x5e, 0x3f, 0xa8, 0x10, 0x77, 0xc0, 0x71, 0x2f, 0x31, 0x9d, 0xa8,
-	0x71, 0x2b, 0xaf, 0x43, 0x8e, 0x59, 0x03, 0xaa, 0xe3, 0x51, 0xe9, 0xe9, 0xcc, 0xc3, 0x3f, 0x71,
-	0x94, 0x44, 0x69, 0xab, 0xa8, 0x0f, 0x0f, 0x58, 0xea, 0x02, 0x24, 0xd7, 0x10, 0x04, 0x51, 0xab,
-	0x4a, 0x5c, 0xc2, 0xbf, 0x81, 0xb2, 0x20, 0x0f, 0xe7, 0xd1, 0x37, 0x0e, 0x8e, 0x42, 0x2f, 0x82,
-	0xa2, 0xcc, 0x5e, 0xcf, 0x95, 0xf3, 0x38, 0xaf, 0xe1, 0xbf, 0x90, 0xf9, 0x09, 0x48, 0x10, 0xbd,
-	0x07, 0x65, 0x9d, 0x36, 0x15, 0x1c, 0xf3, 0xb6, 0x95, 0x94, 0x67, 0x42, 0x58, 0x95, 0x35, 0xba,
-	0x2c, 0x74, 0x35, 0x85, 0xff, 0xf9, 0xe3, 0x06, 0x21, 0x5f, 0xab, 0xb0, 0x0c, 0x15, 0x41, 0xf0,
-	0x7d, 0x02, 0x34, 0x81, 0x7e, 0x01, 0xaf, 0x18, 0x77, 0xfc, 0x82, 0xb4, 0x5d, 0xc0, 0x49, 0xd0,
-	0x76, 0x41, 0x77, 0xa9, 0x16, 0x1e, 0x5e, 0xf3, 0x3c, 0xd0, 0x52, 0x7e, 0x16, 0xbd, 0xc6, 0xd1,
-	0x5e, 0x07, 0xa7, 0x71, 0x37, 0x19, 0x7c, 0x38, 0x1f, 0x3f, 0x0d, 0xf7, 0xb8, 0x1d, 0xec, 0xf1,
-	0x6d, 0x88, 0xf8, 0x56, 0x79, 0xbb, 0x4c, 0x57, 0xf1, 0xe1, 0x42, 0xab, 0x06, 0xcb, 0x5f, 0xa1,
-	0x89, 0x6f, 0xf8, 0xb0, 0xcf, 0x18, 0x95, 0xd3, 0x54, 0x56, 0x61, 0x81, 0x59, 0xa9, 0xe0, 0x2c,
-	0x8e, 0x92, 0xdd, 0x74, 0x83, 0x48, 0x29, 0xb6, 0x66, 0xe8, 0x66, 0x70, 0xce, 0x27, 0xe2, 0x35,
-	0x35, 0x27, 0x2b, 0xbf, 0x9a, 0xa6, 0xf2, 0x10, 0x87, 0xe6, 0xb4, 0x92, 0x9a, 0x7d, 0xa7, 0x4b,
-	0x6a, 0xcc, 0x5b, 0x76, 0x6a, 0x95, 0x7c, 0x2f, 0xf6, 0xb1, 0x2c, 0x4d, 0x8e, 0x7e, 0x75, 0xd7,
-	0x23, 0x2e, 0xf9, 0x05, 0x3d, 0xb9, 0x10, 0xc3, 0xcd, 0x52, 0xa8, 0x1d, 0xf7, 0x6a, 0xc9, 0x2f,
-	0xb6, 0x9f, 0xd2, 0xb2, 0xbd, 0xfa, 0x46, 0xf1, 0x8b, 0xed, 0xa6, 0x41, 0x5c, 0x74, 0x3e, 0x45,
-	0x23, 0x14, 0x83, 0x1b, 0x3d, 0xad, 0xae, 0x94, 0x73, 0x38, 0x55, 0x32, 0x16, 0x03, 0xcc, 0x73,
-	0x3a, 0xd5, 0x35, 0xce, 0x55, 0x6b, 0xb1, 0x89, 0xa8, 0xb4, 0x02, 0x3d, 0xb2, 0xd3, 0x30, 0xe5,
-	0x35, 0xdd, 0x1e, 0x0d, 0x32, 0xfa, 0xc6, 0xaa, 0xf6, 0xf1, 0xaf, 0x41, 0xb6, 0xc3, 0x7f, 0x97,
-	0x8f, 0x7f, 0x03, 0x00, 0x00, 0xff, 0xff, 0xe8, 0x24, 0x4e, 0x8e, 0x6c, 0x04, 0x00, 0x00,
+	// 1045 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdd, 0x6e, 0x23, 0x35,
+	0x14, 0xd6, 0x24, 0xcd, 0x9f, 0x93, 0xfd, 0xa9, 0x29, 0xc5, 0x94, 0x65, 0x37, 0x0c, 0xa5, 0x44,
+	0x02, 0xf5, 0xa2, 0xdc, 0xc0, 0x4a, 0x2b, 0xc4, 0x6e, 0x56, 0xa2, 0x42, 0x5d, 0x90, 0xb3, 0x2b,
+	0xae, 0x9d, 0x99, 0xd3, 0x74, 0xb4, 0x93, 0x71, 0x62, 0x7b, 0xda, 0xe4, 0x39, 0x78, 0x06, 0xde,
+	0x83, 0x07, 0xe2, 0x21, 0xd0, 0x39, 0xf6, 0x24, 0x93, 0x6e, 0xa1, 0xe2, 0xce, 0xdf, 0xe7, 0xe3,
+	0xf3, 0xf3, 0x9d, 0x33, 0xf6, 0xb0, 0xbe, 0x5b, 0x2f, 0xc0, 0x9e, 0x2e, 0x8c, 0x76, 0x9a, 0xf7,
+	0x16, 0x53, 0x67, 0x54, 0xf2, 0x1e, 0x4c, 0xfc, 0x37, 0x63, 0x9d, 0x37, 0x3a, 0x85, 0x0b, 0x3b,
+	0xe3, 0x0f, 0x59, 0x23, 0x1b, 0x8b, 0x68, 0x18, 0x8d, 0x5a, 0xb2, 0x91, 0x8d, 0xf9, 0x21, 0x6b,
+	0x17, 0x3a, 0x85, 0xf3, 0xb1, 0x68, 0x0c, 0xa3, 0x51, 0x4f, 0x06, 0x84, 0xfc, 0xa2, 0x9c, 0xfe,
+	0x02, 0x6b, 0xd1, 0xf4, 0xbc, 0x47, 0xfc, 0x80, 0xb5, 0xf4, 0x4d, 0x01, 0x46, 0xec, 0x11, 0xed,
+	0x01, 0x7f, 0xc2, 0x7a, 0x0b, 0xa3, 0x2f, 0x33, 0xf7, 0x53, 0x92, 0x88, 0x16, 0xed, 0x6c, 0x09,
+	0xf2, 0xa5, 0x75, 0x7e, 0x3e, 0x16, 0xed, 0xe0, 0x8b, 0x10, 0x9d, 0xd2, 0x3a, 0xff, 0x95, 0xfc,
+	0x75, 0xc2, 0xa9, 0x8a, 0xc0, 0x48, 0xcb, 0x52, 0x3b, 0x25, 0xba, 0xc3, 0x68, 0xd4, 0x94, 0x1e,
+	0x20, 0xab, 0xd2, 0xd4, 0x58, 0xd1, 0x1b, 0x36, 0x31, 0x3e, 0x01, 0xfe, 0x98, 0x35, 0x93, 0xdf,
+	0xde, 0x09, 0x46, 0x65, 0xe1, 0x12, 0x63, 0xce, 0x61, 0xae, 0xcd, 0x5a, 0xf4, 0x89, 0x0c, 0x08,
+	0x63, 0x4e, 0x55, 0x91, 0xde, 0x64, 0xa9, 0xbb, 0x12, 0x03, 0xda, 0xda, 0x12, 0x3c, 0x66, 0x83,
+	0xb9, 0x5a, 0x8d, 0x95, 0x53, 0x93, 0x85, 0x4a, 0x40, 0x3c, 0xa0, 0xd0, 0x3b, 0x1c, 0x3f, 0x66,
+	0x0f, 0x94, 0xb5, 0xd9, 0xac, 0x80, 0xd4, 0x1b, 0x3d, 0x24, 0xa3, 0x5d, 0x92, 0x8f, 0xd8, 0xa3,
+	0x85, 0xd1, 0x69, 0x99, 0xb8, 0xec, 0x1a, 0xbc, 0xdd, 0x23, 0xb2, 0xbb, 0x4d, 0x63, 0x46, 0xa5,
+	0xad, 0x7c, 0x3d, 0x26, 0x9b, 0x2d, 0x81, 0x75, 0xdc, 0x40, 0x36, 0xbb, 0x72, 0x62, 0x7f, 0x18,
+	0x8d, 0x22, 0x19, 0x10, 0xea, 0x70, 0xad, 0xf2, 0x2c, 0x15, 0x9c, 0x6a, 0xf0, 0x00, 0x59, 0x03,
+	0xb9, 0x5a, 0x8b, 0x8f, 0x3c, 0x4b, 0x00, 0x7d, 0x58, 0xa7, 0x5c, 0x69, 0xc5, 0x81, 0xd7, 0xc2,
+	0x23, 0x8c, 0xec, 0xb2, 0x39, 0x58, 0xa7, 0xe6, 0x0b, 0xf1, 0xb1, 0x8f, 0xbc, 0x21, 0xb8, 0x60,
+	0x9d, 0x6b, 0x30, 0x36, 0xd3, 0x85, 0x38, 0xa4, 0x63, 0x15, 0xe4, 0x4f, 0x19, 0x33, 0x30, 0x2d,
+	0xb3, 0x3c, 0xcd, 0x8a, 0x99, 0xf8, 0x84, 0x36, 0x6b, 0x0c, 0xfa, 0x35, 0xa0, 0x72, 0x5f, 0x91,
+	0xf0, 0x7e, 0x37, 0x04, 0x4e, 0xa0, 0x5b, 0x89, 0x4f, 0x89, 0x6e, 0xb8, 0x15, 0x62, 0xb3, 0x12,
+	0x47, 0x1e, 0x9b, 0x15, 0xf6, 0x12, 0x56, 0x4e, 0x7c, 0x46, 0xf3, 0x80, 0x4b, 0xfe, 0x03, 0xeb,
+	0x94, 0x16, 0xcf, 0x5a, 0xf1, 0x64, 0xd8, 0x1c, 0xf5, 0xcf, 0x9e, 0x9d, 0x6e, 0x86, 0xfb, 0x34,
+	0x0c, 0xf6, 0xe9, 0x3b, 0x6f, 0xf1, 0xba, 0x70, 0x66, 0x2d, 0x2b, 0x7b, 0xdf, 0xd0, 0xa2, 0x54,
+	0xf9, 0xef, 0x5e, 0xc4, 0xcf, 0x29, 0xd9, 0x1d, 0x0e, 0xcb, 0x29, 0x0b, 0x03, 0x2a, 0x55, 0xd3,
+	0x1c, 0xc4, 0xd3, 0x61, 0x34, 0xea, 0xca, 0x1a, 0xc3, 0x39, 0xdb, 0xbb, 0x52, 0xf6, 0x4a, 0x3c,
+	0xa3, 0x8c, 0x68, 0x8d, 0xe2, 0x4c, 0xf3, 0x57, 0xba, 0x2c, 0x9c, 0x18, 0x7a, 0x71, 0x02, 0x44,
+	0xb1, 0x2f, 0xb3, 0x1c, 0x85, 0xf9, 0x82, 0x3c, 0x05, 0xc4, 0x4f, 0xd8, 0x43, 0x95, 0xe7, 0x3a,
+	0x51, 0xae, 0xea, 0x75, 0x4c, 0x25, 0xdf, 0x62, 0x31, 0xe3, 0x5c, 0x59, 0x27, 0x21, 0x81, 0xec,
+	0x1a, 0x52, 0xf1, 0xa5, 0x1f, 0xc1, 0x3a, 0x87, 0x19, 0x23, 0x9e, 0xe8, 0xd2, 0x24, 0x20, 0x8e,
+	0x7d, 0x03, 0xb6, 0x0c, 0xff, 0x99, 0x0d, 0x8c, 0xb7, 0x7d, 0x8b, 0xed, 0x14, 0x5f, 0x91, 0x6a,
+	0xc7, 0x77, 0xa8, 0x26, 0x6b, 0x66, 0x5e, 0xba, 0x9d, 0x93, 0x7c, 0xc8, 0xfa, 0x5e, 0xca, 0xb7,
+	0xda, 0xa9, 0x5c, 0x9c, 0x50, 0x32, 0x75, 0x8a, 0xbf, 0x64, 0x0c, 0x56, 0x0e, 0x0a, 0x9c, 0x0c,
+	0x2b, 0xbe, 0xa6, 0x48, 0xf1, 0x1d, 0x91, 0x5e, 0x6f, 0x8c, 0x7c, 0x9c, 0xda, 0x29, 0x6c, 0xb9,
+	0x85, 0xa5, 0x18, 0x91, 0x77, 0x5c, 0x1e, 0x3d, 0x67, 0x83, 0x7a, 0x43, 0xd1, 0xe2, 0x3d, 0xac,
+	0xe9, 0xde, 0xea, 0x49, 0x5c, 0x86, 0x0f, 0xa0, 0x04, 0xba, 0xb7, 0x9a, 0xd2, 0x83, 0xe7, 0x8d,
+	0xef, 0xa3, 0xa3, 0x1f, 0xd9, 0xfe, 0x07, 0x65, 0xfd, 0x2f, 0x07, 0x2f, 0xd8, 0xa3, 0x5b, 0xd9,
+	0xde, 0x77, 0x7c, 0x50, 0x3b, 0x1e, 0x2b, 0xd6, 0x9f, 0x64, 0xb3, 0xe2, 0x02, 0xac, 0x55, 0x33,
+	0x40, 0x09, 0x55, 0x92, 0xe0, 0x6c, 0xbc, 0x51, 0x73, 0x08, 0x2e, 0xea, 0x14, 0x0e, 0x58, 0xaa,
+	0x9c, 0x0a, 0x9e, 0x68, 0x8d, 0xdf, 0x10, 0x5e, 0x27, 0xca, 0x95, 0x06, 0xc2, 0x15, 0xbc, 0x25,
+	0xe2, 0x29, 0xeb, 0x4a, 0xfc, 0xb4, 0xf1, 0x46, 0x3f, 0x64, 0x6d, 0x6d, 0xb2, 0x59, 0x56, 0x04,
+	0xd7, 0x01, 0xd1, 0xd8, 0xea, 0x85, 0x25, 0xaf, 0x2d, 0x49, 0x6b, 0x1c, 0xdb, 0x44, 0x17, 0x0e,
+	0x0a, 0x47, 0x3e, 0x07, 0xb2, 0x82, 0x55, 0x0b, 0xf6, 0x36, 0x2d, 0x88, 0x5f, 0xb1, 0xfe, 0xa4,
+	0x50, 0x0b, 0x7b, 0xa5, 0x9d, 0x84, 0xa5, 0xff, 0xa8, 0x97, 0x25, 0x58, 0x77, 0x3e, 0x0e, 0x91,
+	0xb6, 0x44, 0x98, 0x7a, 0x07, 0xa6, 0x7a, 0x46, 0x3c, 0x8a, 0xff, 0x8c, 0xb6, 0x5e, 0x30, 0xd9,
+	0xff, 0xf6, 0x32, 0x62, 0x2d, 0x7c, 0x7e, 0x30, 0x67, 0x1c, 0x23, 0xfe, 0xe1, 0x18, 0x49, 0x6f,
+	0x40, 0x97, 0x44, 0x91, 0x52, 0x11, 0x5d, 0x89, 0x4b, 0xec, 0x87, 0xa3, 0x19, 0xf5, 0x25, 0x78,
+	0x80, 0x2c, 0x18, 0xa3, 0x4d, 0x78, 0x94, 0x3c, 0xa8, 0x8a, 0x6d, 0x6f, 0x8b, 0x7d, 0xc9, 0xda,
+	0x13, 0x58, 0x62, 0x86, 0x61, 0x2f, 0xda, 0xec, 0xf1, 0x13, 0xb6, 0x87, 0x41, 0xa9, 0xb2, 0xbb,
+	0x93, 0xa2, 0xfd, 0xf8, 0x05, 0xeb, 0x49, 0xb0, 0xe5, 0x1c, 0xee, 0x97, 0x8b, 0xb3, 0xbd, 0x4b,
+	0xa3, 0xe7, 0x61, 0xf4, 0x68, 0x1d, 0xff, 0x15, 0x55, 0xe7, 0xef, 0x17, 0xea, 0x1b, 0xd6, 0x29,
+	0x17, 0xa9, 0x72, 0x1b, 0xa9, 0xf6, 0x6b, 0x59, 0xf9, 0x42, 0x64, 0x65, 0x71, 0x87, 0x56, 0x82,
+	0x75, 0xe8, 0xb6, 0xd8, 0x34, 0xbc, 0x82, 0x78, 0x4b, 0x19, 0xb0, 0xeb, 0x22, 0x91, 0xb0, 0x2c,
+	0x33, 0x03, 0x29, 0x09, 0xd7, 0x95, 0xb7, 0xd8, 0xad, 0xae, 0xed, 0x9a, 0xae, 0x71, 0xc1, 0x98,
+	0xf4, 0x39, 0x62, 0x09, 0x67, 0xac, 0x6b, 0x43, 0xeb, 0xa9, 0x82, 0xfe, 0xd9, 0x61, 0x3d, 0xcb,
+	0xed, 0x6c, 0xc9, 0x8d, 0x1d, 0xff, 0x96, 0xb5, 0x0d, 0x69, 0x10, 0xd4, 0x3e, 0xa8, 0x9d, 0xd8,
+	0x88, 0x2b, 0x83, 0x4d, 0xfc, 0x47, 0xc4, 0x1e, 0x5c, 0x64, 0x05, 0x98, 0x89, 0x53, 0xee, 0xdf,
+	0x7e, 0x6f, 0xc2, 0xaf, 0x47, 0x63, 0xe7, 0xd7, 0x43, 0xb0, 0x8e, 0xbe, 0xbc, 0xcc, 0xb3, 0x02,
+	0x82, 0x2e, 0x15, 0xc4, 0xfb, 0x37, 0x2c, 0x27, 0x59, 0x91, 0x40, 0x10, 0x68, 0x87, 0xdb, 0x7d,
+	0x38, 0x5b, 0xb7, 0x1e, 0xce, 0x69, 0x9b, 0x7e, 0xc0, 0xbe, 0xfb, 0x67, 0x00, 0x37, 0x3a, 0x35,
+	0x88, 0x8f, 0x09, 0x00, 0x00,
 }
@@ -0,0 +1,143 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//ReconcileReportTab collection name of results of the last uspace reconciliation of each SN
+var ReconcileReportTab = "ReconcileReport"
+
+//reconcileCheckInterval interval of checking whether reconciliation of SNs is due
+const reconcileCheckInterval = time.Minute
+
+//ReconcileReport result of the last uspace reconciliation of one SN
+type ReconcileReport struct {
+	SNID          int32                `bson:"_id" json:"snID"`
+	UspaceKey     string               `bson:"uspaceKey" json:"uspaceKey"`
+	StartTime     int64                `bson:"startTime" json:"startTime"`
+	FinishTime    int64                `bson:"finishTime" json:"finishTime"`
+	Discrepancies []*UspaceDiscrepancy `bson:"discrepancies" json:"discrepancies"`
+	Error         string               `bson:"error" json:"error"`
+}
+
+func (tracker *MinerTracker) reconcileConfig() *ReconcileConfig {
+	tracker.configLock.RLock()
+	defer tracker.configLock.RUnlock()
+	return tracker.reconcile
+}
+
+//reconcileUspaces reconcile used spaces of miners with shards of each SN periodically until ctx is done,
+//it should be called when current instance becomes leader, reconciliation interrupted by losing leadership
+//is resumed from its checkpoint by the next leader
+func (tracker *MinerTracker) reconcileUspaces(ctx context.Context) {
+	for {
+		conf := tracker.reconcileConfig()
+		for _, ep := range conf.SNURLs {
+			if ctx.Err() != nil {
+				return
+			}
+			if tracker.reconcileDue(ctx, ep.ID, conf.Interval) {
+				tracker.reconcileSN(ctx, conf, ep)
+			}
+		}
+		if !sleepContext(ctx, reconcileCheckInterval) {
+			return
+		}
+	}
+}
+
+//reconcileDue check whether the last reconciliation of SN finished interval(seconds) ago
+func (tracker *MinerTracker) reconcileDue(ctx context.Context, snID int32, interval int) bool {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "reconcileDue", SNID: snID})
+	report := new(ReconcileReport)
+	err := tracker.dbCli.Database(MinerTrackerDB).Collection(ReconcileReportTab).FindOne(ctx, bson.M{"_id": snID}, options.FindOne().SetProjection(bson.M{"finishTime": 1})).Decode(report)
+	if err == mongo.ErrNoDocuments {
+		return true
+	}
+	if err != nil {
+		entry.WithError(err).Errorf("reading reconciliation report of SN%d", snID)
+		return false
+	}
+	return time.Now().Unix()-report.FinishTime >= int64(interval)
+}
+
+//reconcileSN count shards of SN and compare them with the uspaces key owned by SN, the result is saved as report of SN,
+//discrepancies are only reported since uspaces of tracker are overwritten by the next report of SN, they should be
+//corrected in database of SN by correct-uspace command
+func (tracker *MinerTracker) reconcileSN(ctx context.Context, conf *ReconcileConfig, ep *SNEndpoint) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "reconcileSN", SNID: ep.ID})
+	report := &ReconcileReport{SNID: ep.ID, UspaceKey: tracker.syncSvc.uspaceKey(ep.ID), StartTime: time.Now().Unix(), Discrepancies: make([]*UspaceDiscrepancy, 0)}
+	workDB := fmt.Sprintf("%s_sn%d", conf.WorkDB, ep.ID)
+	entry.Infof("reconciling uspaces.%s with shards of SN%d", report.UspaceKey, ep.ID)
+	snCli, err := mongo.Connect(ctx, options.Client().ApplyURI(ep.URL))
+	if err != nil {
+		entry.WithError(err).Errorf("connecting mongoDB of SN%d", ep.ID)
+		report.Error = err.Error()
+	} else {
+		defer snCli.Disconnect(context.Background())
+		discrepancies, err := CorrectUspace(ctx, &UspaceCorrection{
+			ShardCli:        snCli,
+			ShardDB:         conf.ShardDB,
+			ShardCollection: conf.ShardCollection,
+			NodeCli:         tracker.dbCli,
+			NodeDB:          MinerTrackerDB,
+			NodeCollection:  NodeTab,
+			WorkDB:          workDB,
+			SNID:            int(ep.ID),
+			UspaceKey:       report.UspaceKey,
+			BatchSize:       int64(conf.BatchSize),
+			DryRun:          true,
+		})
+		if ctx.Err() != nil {
+			entry.Infof("reconciliation of SN%d is interrupted, it will be resumed from checkpoint", ep.ID)
+			return
+		}
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		for _, d := range discrepancies {
+			entry.WithField(MinerID, d.MinerID).Infof("uspaces.%s of miner %d: %d, calculated: %d", report.UspaceKey, d.MinerID, d.Space, d.Calculated)
+		}
+		if err != nil {
+			entry.WithError(err).Errorf("reconciling uspaces of SN%d", ep.ID)
+			report.Error = err.Error()
+		}
+	}
+	report.FinishTime = time.Now().Unix()
+	_, err = tracker.dbCli.Database(MinerTrackerDB).Collection(ReconcileReportTab).ReplaceOne(ctx, bson.M{"_id": ep.ID}, report, options.Replace().SetUpsert(true))
+	if err != nil {
+		entry.WithError(err).Errorf("saving reconciliation report of SN%d", ep.ID)
+		return
+	}
+	entry.Infof("reconciliation of SN%d finished, %d miners with discrepancy found", ep.ID, len(report.Discrepancies))
+}
+
+//ReconcileHandler show results of the last uspace reconciliation of each SN
+func (tracker *MinerTracker) ReconcileHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "ReconcileHandler"})
+	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(ReconcileReportTab)
+	cur, err := collection.Find(context.Background(), bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		entry.WithError(err).Error("finding reconciliation reports")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	defer cur.Close(context.Background())
+	reports := make([]*ReconcileReport, 0)
+	for cur.Next(context.Background()) {
+		report := new(ReconcileReport)
+		err := cur.Decode(report)
+		if err != nil {
+			entry.WithError(err).Error("decoding reconciliation report")
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		reports = append(reports, report)
+	}
+	return c.JSON(http.StatusOK, reports)
+}
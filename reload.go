@@ -0,0 +1,148 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//configuration keys which take effect without restarting
+var reloadableFields = map[string]bool{
+	AuramqClientAllSNURLsField:   true,
+	AuramqFederationPeersField:   true,
+	MinerStatAllSyncURLsField:    true,
+	MinerStatBatchSizeField:      true,
+	MinerStatWaitTimeField:       true,
+	MinerStatSkipTimeField:       true,
+	LoggerLevelField:             true,
+	LoggerFormatField:            true,
+	LoggerLevelsField:            true,
+	AlertWebhookURLField:         true,
+	AlertSecretField:             true,
+	AlertSecretFileField:         true,
+	AlertRetriesField:            true,
+	AlertRetryIntervalField:      true,
+	AlertCheckIntervalField:      true,
+	AlertRulesField:              true,
+	OfflineThresholdField:        true,
+	OfflineIntervalField:         true,
+	ReconcileSNURLsField:         true,
+	ReconcileIntervalField:       true,
+	ReconcileBatchSizeField:      true,
+	ReconcileShardDBField:        true,
+	ReconcileShardTabField:       true,
+	ReconcileWorkDBField:         true,
+	MiscRefreshAuthIntervalField: true,
+	MiscRefreshAuthWorkersField:  true,
+	MiscAuthNegativeTTLField:     true,
+}
+
+//values of these keys are not shown in configuration changes
+var secretFields = map[string]bool{
+	MongoDBURLField:             true,
+	AuramqClientPrivateKeyField: true,
+	MiscAdminTokenField:         true,
+	AlertSecretField:            true,
+	ReconcileSNURLsField:        true,
+}
+
+//statWorker tracking worker of one SN
+type statWorker struct {
+	url    string
+	cancel context.CancelFunc
+}
+
+//ConfigChange one changed configuration key
+type ConfigChange struct {
+	Key        string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c *ConfigChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+func (ep *SNEndpoint) String() string {
+	if ep.UspaceKey != "" {
+		return fmt.Sprintf("%d=%s(%s)", ep.ID, ep.URL, ep.UspaceKey)
+	}
+	return fmt.Sprintf("%d=%s", ep.ID, ep.URL)
+}
+
+//DiffConfig compare two configurations and returns all changed keys in the order of configuration struct
+func DiffConfig(oldConfig, newConfig *Config) []*ConfigChange {
+	changes := make([]*ConfigChange, 0)
+	diffConfig("", reflect.ValueOf(oldConfig), reflect.ValueOf(newConfig), &changes)
+	return changes
+}
+
+func diffConfig(prefix string, oldValue, newValue reflect.Value, changes *[]*ConfigChange) {
+	t := oldValue.Type().Elem()
+	//missing section is compared as empty section
+	if oldValue.IsNil() {
+		oldValue = reflect.New(t)
+	}
+	if newValue.IsNil() {
+		newValue = reflect.New(t)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("mapstructure")
+		oldField, newField := oldValue.Elem().Field(i), newValue.Elem().Field(i)
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			diffConfig(key+".", oldField, newField, changes)
+			continue
+		}
+		oldStr, newStr := configValueString(oldField), configValueString(newField)
+		if oldStr == newStr {
+			continue
+		}
+		if secretFields[key] {
+			oldStr, newStr = "******", "******"
+		}
+		*changes = append(*changes, &ConfigChange{Key: key, Old: oldStr, New: newStr, Reloadable: reloadableFields[key]})
+	}
+}
+
+func configValueString(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ","))
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(v.Interface()))
+}
+
+func (tracker *MinerTracker) statConfig() *MinerStatConfig {
+	tracker.configLock.RLock()
+	defer tracker.configLock.RUnlock()
+	return tracker.minerStat
+}
+
+func (tracker *MinerTracker) miscConfig() *MiscConfig {
+	tracker.configLock.RLock()
+	defer tracker.configLock.RUnlock()
+	return tracker.params
+}
+
+//Reload apply runtime-tunable configuration: miner log tracking parameters and SN list, auth refreshing parameters,
+//MQ server list of SNs and peers, alert rules and webhooks, offline detection parameters, uspace reconciliation, tracking workers and MQ connections are started or stopped for added or removed SNs,
+//other changes take effect after restarting
+func (tracker *MinerTracker) Reload(config *Config) {
+	misc := *config.Misc
+	tracker.configLock.Lock()
+	misc.AdminToken = tracker.params.AdminToken
+	tracker.minerStat = config.MinerStat
+	tracker.offline = config.Offline
+	tracker.reconcile = config.Reconcile
+	tracker.params = &misc
+	tracker.configLock.Unlock()
+	tracker.alerts.Reload(config.Alert)
+	tracker.UpdateTracking(config.MinerStat.AllSyncURLs)
+	tracker.syncSvc.Reload(&misc, config.AuraMQ.ClientConfig.AllSNURLs, config.AuraMQ.Federation.Peers)
+}
@@ -0,0 +1,107 @@
+package yttracker
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//actions of replaying miner logs
+const (
+	ActionInsert  = "insert"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionNothing = "nothing"
+)
+
+//ReplayChange change made or to be made by replaying one miner log
+type ReplayChange struct {
+	LogID   int64
+	MinerID int32
+	Type    string
+	Action  string
+}
+
+//SetTrackProgress set start cursor of tracking progress of SN, returns the old cursor
+func SetTrackProgress(ctx context.Context, cli *mongo.Client, ep *SNEndpoint, start int64) (int64, error) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "SetTrackProgress"})
+	collection := cli.Database(MinerTrackerDB).Collection(TrackProgressTab)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	old := new(TrackProgress)
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": ep.ID}, bson.M{"$set": bson.M{"start": start, "timestamp": time.Now().Unix(), "url": ep.URL}}, opts).Decode(old)
+	if err != nil && err != mongo.ErrNoDocuments {
+		entry.WithError(err).Errorf("set tracking progress of SN%d to %d", ep.ID, start)
+		return 0, err
+	}
+	entry.Infof("tracking progress of SN%d: %d -> %d", ep.ID, old.Start, start)
+	return old.Start, nil
+}
+
+//ReplayMinerLogs replay miner logs in [from, to) fetched from sync URL, to = 0 means no upper bound,
+//miner collection is only read but not modified when dryRun is true, logs not newer than lastLogID of miner are
+//skipped unless force is true, so that replaying an old range never deletes a miner registered again later or
+//moves its lastLogID backwards
+func ReplayMinerLogs(ctx context.Context, cli *mongo.Client, httpCli *http.Client, url string, from, to int64, batchSize int, dryRun, force bool) ([]*ReplayChange, error) {
+	entry := log.WithFields(log.Fields{Component: ComponentTracking, Function: "ReplayMinerLogs"})
+	collection := cli.Database(MinerTrackerDB).Collection(NodeTab)
+	changes := make([]*ReplayChange, 0)
+	start := from
+	for {
+		minerLogs, err := GetMinerLogs(httpCli, url, start, batchSize, time.Now().Unix())
+		if err != nil {
+			return changes, err
+		}
+		for _, item := range minerLogs.MinerLogs {
+			if to != 0 && item.ID >= to {
+				return changes, nil
+			}
+			change, err := replayMinerLog(ctx, collection, item, dryRun, force)
+			if err != nil {
+				entry.WithError(err).Errorf("replaying miner log %d", item.ID)
+				return changes, err
+			}
+			if change != nil {
+				changes = append(changes, change)
+			}
+		}
+		if !minerLogs.More {
+			return changes, nil
+		}
+		start = minerLogs.Next
+	}
+}
+
+func replayMinerLog(ctx context.Context, collection *mongo.Collection, item *MinerLog, dryRun, force bool) (*ReplayChange, error) {
+	change := &ReplayChange{LogID: item.ID, MinerID: item.MinerID, Type: item.Type, Action: ActionNothing}
+	if item.Type == NEW && item.FromStatus == -1 {
+		change.Action = ActionUpdate
+	} else if item.Type == DELETE && item.ToStatus == -1 {
+		change.Action = ActionDelete
+	} else {
+		return nil, nil
+	}
+	miner := new(Node)
+	err := collection.FindOne(ctx, bson.M{"_id": item.MinerID}, options.FindOne().SetProjection(bson.M{"lastLogID": 1})).Decode(miner)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == mongo.ErrNoDocuments {
+		if change.Action == ActionUpdate {
+			change.Action = ActionInsert
+		} else {
+			change.Action = ActionNothing
+		}
+	} else if !force && miner.LastLogID >= item.ID {
+		change.Action = ActionNothing
+	}
+	if dryRun {
+		return change, nil
+	}
+	_, err = applyMinerLog(ctx, collection, item, !force)
+	return change, err
+}
@@ -0,0 +1,165 @@
+package yttracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aurawing/auramq"
+	"github.com/aurawing/auramq/msg"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//count of snapshots and replays being sent at the same time, requests exceeding this value are rejected
+const maxConcurrentRequests = 4
+
+//handleRequest process requests published to snapshot or resume topic, or sent to client ID of current instance
+func (s *Service) handleRequest(m *msg.Message) {
+	if m.GetSender() == "" || m.GetSender() == s.clientConf.ClientID {
+		return
+	}
+	switch {
+	case m.GetType() == auramq.P2P:
+		s.handleP2PRequest(m)
+	case m.GetDestination() == s.snapshotConf.Topic:
+		s.handleSnapshotRequest(m)
+	case m.GetDestination() == s.replayConf.RequestTopic:
+		s.handleResumeRequest(m)
+	}
+}
+
+//handleP2PRequest dispatch request wrapped in RequestMsg sent to client ID of current instance by its type
+func (s *Service) handleP2PRequest(m *msg.Message) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "handleP2PRequest", ClientID: m.GetSender()})
+	req := new(pb.RequestMsg)
+	err := proto.Unmarshal(m.Content, req)
+	switch {
+	case err != nil:
+		entry.WithError(err).Warn("decoding RequestMsg failed")
+	case req.Snapshot != nil:
+		s.startSnapshot(m.GetSender(), req.Snapshot)
+	case req.Resume != nil:
+		s.startResume(m.GetSender(), req.Resume)
+	default:
+		entry.Warn("no request is set in RequestMsg")
+	}
+}
+
+//handleSnapshotRequest decode request published to snapshot topic and start sending snapshot to requester
+func (s *Service) handleSnapshotRequest(m *msg.Message) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "handleSnapshotRequest", ClientID: m.GetSender()})
+	req := new(pb.SnapshotReq)
+	err := proto.Unmarshal(m.Content, req)
+	if err != nil {
+		entry.WithError(err).Warn("decoding SnapshotReq failed")
+		s.sendMessage(m.GetSender(), &pb.SnapshotMsg{End: true, Error: fmt.Sprintf("invalid snapshot request: %s", err)})
+		return
+	}
+	s.startSnapshot(m.GetSender(), req)
+}
+
+//startSnapshot start sending snapshot to requester, request is rejected if too many requests are being processed
+func (s *Service) startSnapshot(to string, req *pb.SnapshotReq) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "startSnapshot", ClientID: to})
+	select {
+	case s.requestSem <- struct{}{}:
+	default:
+		entry.Warn("too many snapshot requests")
+		s.sendMessage(to, &pb.SnapshotMsg{RequestID: req.RequestID, End: true, Error: "too many snapshot requests, try again later"})
+		return
+	}
+	go func() {
+		defer func() { <-s.requestSem }()
+		s.sendSnapshot(to, req)
+	}()
+}
+
+//sendSnapshot send all miners matching filter of request to client in batches, followed by a message marked as end,
+//which carries the last sequence number reserved by any instance before reading miners, updates of sequence numbers
+//not greater than it are already contained in snapshot
+func (s *Service) sendSnapshot(to string, req *pb.SnapshotReq) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "sendSnapshot", ClientID: to})
+	conf := s.snapshotConf
+	fail := func(err error) {
+		entry.WithError(err).Errorf("sending snapshot of request %s failed", req.RequestID)
+		s.sendMessage(to, &pb.SnapshotMsg{RequestID: req.RequestID, End: true, Error: err.Error()})
+	}
+	filter := bson.M{}
+	if req.Filter != "" {
+		err := json.Unmarshal([]byte(req.Filter), &filter)
+		if err != nil {
+			fail(fmt.Errorf("invalid filter: %s", err))
+			return
+		}
+	}
+	//miner is saved to database before its update is assigned a sequence number
+	seq, _, err := s.updates.lastSeq(context.Background())
+	if err != nil {
+		fail(err)
+		return
+	}
+	collection := s.mongoCli.Database(MinerTrackerDB).Collection(NodeTab)
+	cur, err := collection.Find(context.Background(), filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer cur.Close(context.Background())
+	var total int64
+	batch := make([]*pb.NodeMsg, 0, conf.BatchSize)
+	for cur.Next(context.Background()) {
+		node := new(Node)
+		err := cur.Decode(node)
+		if err != nil {
+			fail(err)
+			return
+		}
+		m, err := node.Convert()
+		if err != nil {
+			fail(err)
+			return
+		}
+		batch = append(batch, m)
+		if len(batch) < conf.BatchSize {
+			continue
+		}
+		if !s.sendMessage(to, &pb.SnapshotMsg{RequestID: req.RequestID, Nodes: batch}) {
+			entry.Errorf("sending snapshot of request %s aborted", req.RequestID)
+			return
+		}
+		total += int64(len(batch))
+		batch = make([]*pb.NodeMsg, 0, conf.BatchSize)
+		time.Sleep(time.Duration(conf.BatchInterval) * time.Millisecond)
+	}
+	if err := cur.Err(); err != nil {
+		fail(err)
+		return
+	}
+	total += int64(len(batch))
+	if s.sendMessage(to, &pb.SnapshotMsg{RequestID: req.RequestID, Nodes: batch, End: true, Total: total, Seq: seq}) {
+		entry.Infof("snapshot of request %s sent: %d miners", req.RequestID, total)
+	}
+}
+
+//sendMessage send protobuf message to client, retry for a while if sending buffer is full
+func (s *Service) sendMessage(to string, m proto.Message) bool {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "sendMessage", ClientID: to})
+	b, err := proto.Marshal(m)
+	if err != nil {
+		entry.WithError(err).Errorf("encoding %T", m)
+		return false
+	}
+	for i := 0; i < 100; i++ {
+		if s.client.Send(to, b) {
+			return true
+		}
+		time.Sleep(time.Duration(10) * time.Millisecond)
+	}
+	entry.Warn("sending buffer is full")
+	return false
+}
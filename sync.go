@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,7 +18,6 @@ import (
 	ebclient "github.com/aurawing/auramq/embed/cli"
 	"github.com/aurawing/auramq/msg"
 	"github.com/aurawing/auramq/ws"
-	wsclient "github.com/aurawing/auramq/ws/cli"
 	"github.com/aurawing/eos-go"
 	"github.com/golang/protobuf/proto"
 	log "github.com/sirupsen/logrus"
@@ -25,25 +25,69 @@ import (
 	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
 )
 
+//StaleReportTab collection of count of stale reports rejected from each SN
+var StaleReportTab = "StaleReport"
+
 //Service sync service
 type Service struct {
-	client   auramq.Client
-	mongoCli *mongo.Client
+	client       auramq.Client
+	mongoCli     *mongo.Client
+	authCache    *AuthCache
+	clientConf   *ClientConfig
+	credential   []byte
+	callback     func(int32, *msg.Message)
+	lock         sync.Mutex
+	params       *MiscConfig
+	links        map[int32]*mqLink
+	elector      *LeaderElector
+	topic        string
+	federation   *federation
+	snapshotConf *SnapshotConfig
+	replayConf   *ReplayConfig
+	updates      *updateLog
+	requestSem   chan struct{}
+	alerts       *AlertManager
+	stateTopic   string
+	uspaceKeys   map[int32]string
 }
 
-//StartSync start syncing
-func StartSync(api *eos.API, mongoCli *mongo.Client, serverConf *ServerConfig, clientConf *ClientConfig, miscConf *MiscConfig) (*Service, error) {
-	entry := log.WithFields(log.Fields{Function: "StartSync"})
-	refreshAuth(api, mongoCli)
+//StartSync start syncing, stable statistics of miners are counted only when current instance is leader,
+//miner information is relayed between tracker instances if peers are configured in fedConf, and snapshot of miners
+//is sent to subscribers requesting it, each update is assigned a sequence number and retained for subscribers resuming
+//after disconnection
+func StartSync(api *eos.API, mongoCli *mongo.Client, serverConf *ServerConfig, clientConf *ClientConfig, fedConf *FederationConfig, snapshotConf *SnapshotConfig, replayConf *ReplayConfig, offlineConf *OfflineConfig, miscConf *MiscConfig, elector *LeaderElector, alerts *AlertManager) (*Service, error) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "StartSync"})
+	syncService := new(Service)
+	syncService.mongoCli = mongoCli
+	syncService.params = miscConf
+	syncService.links = make(map[int32]*mqLink)
+	syncService.uspaceKeys = make(map[int32]string)
+	syncService.elector = elector
+	syncService.alerts = alerts
+	syncService.stateTopic = offlineConf.Topic
+	syncService.snapshotConf = snapshotConf
+	syncService.replayConf = replayConf
+	syncService.requestSem = make(chan struct{}, maxConcurrentRequests)
+	syncService.updates = newUpdateLog(mongoCli.Database(MinerTrackerDB), elector.InstanceID())
+	syncService.authCache = NewAuthCache(mongoCli, miscConf.AuthNegativeTTL)
+	refreshAuth(api, mongoCli, miscConf.RefreshAuthWorkers)
+	err := syncService.authCache.Load(context.Background())
+	if err != nil {
+		entry.WithError(err).Error("loading auth cache")
+		return nil, err
+	}
+	syncService.authCache.Watch(context.Background())
 	go func() {
 		for {
-			time.Sleep(time.Duration(miscConf.RefreshAuthInterval) * time.Second)
-			refreshAuth(api, mongoCli)
+			time.Sleep(time.Duration(syncService.miscConfig().RefreshAuthInterval) * time.Second)
+			refreshAuth(api, mongoCli, syncService.miscConfig().RefreshAuthWorkers)
+			err := syncService.authCache.Load(context.Background())
+			if err != nil {
+				entry.WithError(err).Error("reloading auth cache")
+			}
 		}
 	}()
 
-	syncService := new(Service)
-	syncService.mongoCli = mongoCli
 	router := auramq.NewRouter(serverConf.RouterBufferSize)
 	go router.Run()
 	wsbroker := ws.NewBroker(router, serverConf.BindAddr, true, syncService.auth, serverConf.SubscriberBufferSize, serverConf.ReadBufferSize, serverConf.WriteBufferSize, serverConf.PingWait, serverConf.ReadWait, serverConf.WriteWait)
@@ -65,7 +109,7 @@ func StartSync(api *eos.API, mongoCli *mongo.Client, serverConf *ServerConfig, c
 		return nil, err
 	}
 
-	cli, err := ebclient.Connect(ebbroker.(*embed.Broker), func(msg *msg.Message) {}, &msg.AuthReq{Id: clientConf.ClientID, Credential: crendData}, []string{serverConf.MinerSyncTopic})
+	cli, err := ebclient.Connect(ebbroker.(*embed.Broker), syncService.handleRequest, &msg.AuthReq{Id: clientConf.ClientID, Credential: crendData}, []string{serverConf.MinerSyncTopic, snapshotConf.Topic, replayConf.RequestTopic})
 	if err != nil {
 		entry.WithError(err).Error("connecting to embeded broker")
 		return nil, err
@@ -75,7 +119,11 @@ func StartSync(api *eos.API, mongoCli *mongo.Client, serverConf *ServerConfig, c
 	}()
 	entry.Info("create embeded broker successful")
 	syncService.client = cli
-	callback := func(msg *msg.Message) {
+	syncService.topic = serverConf.MinerSyncTopic
+	syncService.clientConf = clientConf
+	syncService.credential = crendData
+	go syncService.runPublisher()
+	syncService.callback = func(snID int32, msg *msg.Message) {
 		if msg.GetType() == auramq.BROADCAST {
 			if msg.GetDestination() == clientConf.MinerSyncTopic {
 				nodemsg := new(pb.NodeMsg)
@@ -90,114 +138,324 @@ func StartSync(api *eos.API, mongoCli *mongo.Client, serverConf *ServerConfig, c
 					entry.WithError(err).Error("convert protobuf message to node")
 					return
 				}
-				syncNode(mongoCli, node, cli, serverConf.MinerSyncTopic)
+				syncService.syncNode(snID, node)
 			}
 		}
 	}
 
-	for i, url := range clientConf.AllSNURLs {
-		wsurl := url
-		index := i
-		go func() {
-			for {
-				cli, err := wsclient.Connect(wsurl, callback, &msg.AuthReq{Id: clientConf.ClientID, Credential: crendData}, []string{clientConf.MinerSyncTopic}, clientConf.SubscriberBufferSize, clientConf.PingWait, clientConf.ReadWait, clientConf.WriteWait)
-				if err != nil {
-					entry.WithError(err).Errorf("connecting to SN%d", index)
-					time.Sleep(time.Duration(3) * time.Second)
-					continue
-				}
-				entry.Infof("remote MQ server SN%d connected: %s", index, wsurl)
-				cli.Run()
-				entry.Infof("re-connect MQ SN%d server: %s", index, wsurl)
-				time.Sleep(time.Duration(3) * time.Second)
-			}
-		}()
-	}
+	syncService.federation = newFederation(elector.InstanceID(), fedConf)
+	syncService.UpdateSNLinks(clientConf.AllSNURLs)
+	syncService.UpdatePeers(fedConf.Peers)
 
 	return syncService, nil
 }
 
-func syncNode(cli *mongo.Client, node *Node, mqcli auramq.Client, topic string) error {
-	entry := log.WithFields(log.Fields{Function: "syncNode"})
+//mqLink connection to a remote MQ server
+type mqLink struct {
+	url     string
+	cancel  context.CancelFunc
+	lock    sync.Mutex
+	stopped bool
+	cli     *wsSubscriber
+}
+
+//attach set current client of link, returns false if link has been stopped
+func (link *mqLink) attach(cli *wsSubscriber) bool {
+	link.lock.Lock()
+	defer link.lock.Unlock()
+	if link.stopped {
+		return false
+	}
+	link.cli = cli
+	return true
+}
+
+func (link *mqLink) stop() {
+	link.lock.Lock()
+	defer link.lock.Unlock()
+	link.stopped = true
+	link.cancel()
+	if link.cli != nil {
+		link.cli.Close()
+		link.cli = nil
+	}
+}
+
+//UpdateSNLinks connect to MQ servers of added SNs and disconnect from removed SNs,
+//links of SNs whose URL changed are re-established, uspace keys of SNs are updated as well
+func (s *Service) UpdateSNLinks(endpoints []*SNEndpoint) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "UpdateSNLinks"})
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	urls := make(map[int32]string)
+	s.uspaceKeys = make(map[int32]string)
+	for _, ep := range endpoints {
+		urls[ep.ID] = ep.URL
+		s.uspaceKeys[ep.ID] = ep.UspacesKey()
+	}
+	for snID, link := range s.links {
+		if url, ok := urls[snID]; !ok || url != link.url {
+			link.stop()
+			delete(s.links, snID)
+			entry.WithField(SNID, snID).Infof("disconnected from MQ server SN%d: %s", snID, link.url)
+		}
+	}
+	for _, ep := range endpoints {
+		if _, ok := s.links[ep.ID]; !ok {
+			s.links[ep.ID] = s.connectSN(ep.ID, ep.URL)
+		}
+	}
+}
+
+func (s *Service) connectSN(snID int32, wsurl string) *mqLink {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "connectSN", SNID: snID})
+	return s.connectMQ(entry, fmt.Sprintf("SN%d", snID), wsurl, s.clientConf.ClientID, s.clientConf.MinerSyncTopic, func(m *msg.Message) {
+		s.callback(snID, m)
+	})
+}
+
+//connectMQ keep connecting to remote MQ server and subscribing topic until the returned link is stopped
+func (s *Service) connectMQ(entry *log.Entry, name, wsurl, clientID, topic string, callback func(*msg.Message)) *mqLink {
+	ctx, cancel := context.WithCancel(context.Background())
+	link := &mqLink{url: wsurl, cancel: cancel}
+	cb := func(msg *msg.Message) {
+		if ctx.Err() == nil {
+			callback(msg)
+		}
+	}
+	conf := s.clientConf
+	go func() {
+		for ctx.Err() == nil {
+			cli, err := dialSubscriber(wsurl, cb, &msg.AuthReq{Id: clientID, Credential: s.credential}, []string{topic}, conf.PingWait, conf.ReadWait, conf.WriteWait)
+			if err != nil {
+				entry.WithError(err).Errorf("connecting to %s", name)
+				sleepContext(ctx, time.Duration(3)*time.Second)
+				continue
+			}
+			if !link.attach(cli) {
+				cli.Close()
+				return
+			}
+			entry.Infof("remote MQ server %s connected: %s", name, wsurl)
+			cli.Run()
+			link.attach(nil)
+			if ctx.Err() != nil {
+				return
+			}
+			entry.Infof("re-connect MQ %s server: %s", name, wsurl)
+			sleepContext(ctx, time.Duration(3)*time.Second)
+		}
+	}()
+	return link
+}
+
+func (s *Service) miscConfig() *MiscConfig {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.params
+}
+
+//Reload apply runtime-tunable misc configuration, MQ server list of SNs and peers
+func (s *Service) Reload(miscConf *MiscConfig, endpoints []*SNEndpoint, peers []string) {
+	s.lock.Lock()
+	s.params = miscConf
+	s.lock.Unlock()
+	s.authCache.SetNegativeTTL(miscConf.AuthNegativeTTL)
+	s.UpdateSNLinks(endpoints)
+	s.UpdatePeers(peers)
+}
+
+//syncNode save miner information received from SN and publish it to subscribers and peers, stable statistics of
+//the miner are counted and alert rules are evaluated only by leader, so that each message is counted once when
+//multiple instances are running, receive time of the message from SN identified by snID is recorded as well,
+//reports older than the stored one are counted for stability but never overwrite miner information, and only the
+//uspaces key owned by the SN is updated
+func (s *Service) syncNode(snID int32, node *Node) error {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "syncNode", MinerID: node.ID})
 	if node.ID == 0 {
 		return errors.New("miner ID cannot be 0")
 	}
-	collection := cli.Database(MinerTrackerDB).Collection(NodeTab)
+	collection := s.mongoCli.Database(MinerTrackerDB).Collection(NodeTab)
 	if node.Uspaces == nil {
 		node.Uspaces = make(map[string]int64)
 	}
-	_, err := collection.InsertOne(context.Background(), bson.M{"_id": node.ID, "nodeid": node.NodeID, "pubkey": node.PubKey, "owner": node.Owner, "profitAcc": node.ProfitAcc, "poolID": node.PoolID, "poolOwner": node.PoolOwner, "quota": node.Quota, "addrs": node.Addrs, "cpu": node.CPU, "memory": node.Memory, "bandwidth": node.Bandwidth, "maxDataSpace": node.MaxDataSpace, "assignedSpace": node.AssignedSpace, "productiveSpace": node.ProductiveSpace, "usedSpace": node.UsedSpace, "uspaces": node.Uspaces, "weight": node.Weight, "valid": node.Valid, "relay": node.Relay, "status": node.Status, "timestamp": node.Timestamp, "version": node.Version, "rebuilding": node.Rebuilding, "realSpace": node.RealSpace, "tx": node.Tx, "rx": node.Rx, "other": node.Other, "manualWeight": node.ManualWeight, "unreadable": node.Unreadable, "hashID": node.HashID, "blCount": node.BlCount, "filing": node.Filing, "allocatedSpace": node.AllocatedSpace, "stableStat": &StableStatistics{StartTime: time.Now().Unix(), Counter: 0, Ratio: 1}})
+	//ext must be a document so that extensions can be merged into it
+	if node.Ext == nil {
+		node.Ext = bson.M{}
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	snKey := fmt.Sprintf("sn%d", snID)
+	node.LastReceived = now
+	node.LastSource = snID
+	node.ReceiveTimes = map[string]int64{snKey: now}
+	uspaceKey := s.uspaceKey(snID)
+	uspaces := make(map[string]int64)
+	for k, v := range node.Uspaces {
+		if k == uspaceKey {
+			uspaces[k] = v
+		} else {
+			entry.Tracef("uspaces.%s of miner %d reported by SN%d is ignored", k, node.ID, snID)
+		}
+	}
+	node.Uspaces = uspaces
+	node.UspaceTotal = node.UspacesSum()
+	for k := range node.Ext {
+		if !validExtKey(k) {
+			entry.Warnf("extension %q of miner %d reported by SN%d is ignored", k, node.ID, snID)
+			delete(node.Ext, k)
+		}
+	}
+	doc := node.insertDoc()
+	doc["stableStat"] = &StableStatistics{StartTime: time.Now().Unix(), Counter: 0, Ratio: 1}
+	doc["uspaceTimes"] = map[string]int64{uspaceKey: node.Timestamp}
+	_, err := collection.InsertOne(context.Background(), doc)
 	if err != nil {
-		errstr := err.Error()
-		if !strings.ContainsAny(errstr, "duplicate key error") {
+		if !isDuplicateKeyError(err) {
 			entry.WithError(err).Warnf("inserting miner %d to database", node.ID)
 			return err
 		}
-		oldNode := new(Node)
-		err := collection.FindOne(context.Background(), bson.M{"_id": node.ID}).Decode(oldNode)
-		if err != nil {
-			entry.WithError(err).Warnf("fetching miner %d", node.ID)
-			return err
-		}
-		if oldNode.StableStat == nil {
-			oldNode.StableStat = &StableStatistics{StartTime: time.Now().Unix(), Counter: 0, Ratio: 1}
-		}
-		newCounter := oldNode.StableStat.Counter + 1
-		newRatio := float32(newCounter*60) / float32(time.Now().Unix()-oldNode.StableStat.StartTime)
-		if newRatio > 1 {
-			newRatio = 1
+		cond := node.updateDoc()
+		cond[fmt.Sprintf("receiveTimes.%s", snKey)] = now
+		var oldNode *Node
+		if s.elector.IsLeader() {
+			oldNode = new(Node)
+			err := collection.FindOne(context.Background(), bson.M{"_id": node.ID}).Decode(oldNode)
+			if err != nil {
+				entry.WithError(err).Warnf("fetching miner %d", node.ID)
+				return err
+			}
+			if oldNode.StableStat == nil {
+				oldNode.StableStat = &StableStatistics{StartTime: time.Now().Unix(), Counter: 0, Ratio: 1}
+			}
+			newCounter := oldNode.StableStat.Counter + 1
+			newRatio := float32(newCounter*60) / float32(time.Now().Unix()-oldNode.StableStat.StartTime)
+			if newRatio > 1 {
+				newRatio = 1
+			}
+			cond["stableStat"] = &StableStatistics{StartTime: oldNode.StableStat.StartTime, Counter: newCounter, Ratio: newRatio}
 		}
-		cond := bson.M{"nodeid": node.NodeID, "pubkey": node.PubKey, "owner": node.Owner, "profitAcc": node.ProfitAcc, "poolID": node.PoolID, "poolOwner": node.PoolOwner, "quota": node.Quota, "addrs": node.Addrs, "cpu": node.CPU, "memory": node.Memory, "bandwidth": node.Bandwidth, "maxDataSpace": node.MaxDataSpace, "assignedSpace": node.AssignedSpace, "productiveSpace": node.ProductiveSpace, "usedSpace": node.UsedSpace, "weight": node.Weight, "valid": node.Valid, "relay": node.Relay, "status": node.Status, "timestamp": node.Timestamp, "version": node.Version, "rebuilding": node.Rebuilding, "realSpace": node.RealSpace, "tx": node.Tx, "rx": node.Rx, "manualWeight": node.ManualWeight, "unreadable": node.Unreadable, "hashID": node.HashID, "blCount": node.BlCount, "filing": node.Filing, "allocatedSpace": node.AllocatedSpace, "stableStat": &StableStatistics{StartTime: oldNode.StableStat.StartTime, Counter: newCounter, Ratio: newRatio}}
 		if len(node.Other) > 0 {
 			cond["other"] = node.Other
 		}
 		for k, v := range node.Uspaces {
 			cond[fmt.Sprintf("uspaces.%s", k)] = v
+			cond[fmt.Sprintf("uspaceTimes.%s", k)] = node.Timestamp
+		}
+		//extensions not reported this time are kept
+		for k, v := range node.Ext {
+			cond[fmt.Sprintf("ext.%s", k)] = v
 		}
 		opts := new(options.FindOneAndUpdateOptions)
 		opts = opts.SetReturnDocument(options.After)
-		result := collection.FindOneAndUpdate(context.Background(), bson.M{"_id": node.ID}, bson.M{"$set": cond}, opts)
+		//uspaceTotal is computed from uspaces after it is updated in the same write
+		pipeline := mongo.Pipeline{literalSet(cond), uspaceTotalStage}
+		result := collection.FindOneAndUpdate(context.Background(), bson.M{"_id": node.ID, "timestamp": bson.M{"$not": bson.M{"$gt": node.Timestamp}}}, pipeline, opts)
 		updatedNode := new(Node)
-		err = result.Decode(updatedNode)
+		raw, err := result.DecodeBytes()
+		if err == mongo.ErrNoDocuments {
+			return s.rejectStale(snID, node, cond, uspaceKey)
+		}
+		if err == nil {
+			err = bson.Unmarshal(raw, updatedNode)
+		}
 		if err != nil {
 			entry.WithError(err).Warnf("updating record of miner %d", node.ID)
 			return err
 		}
+		s.clearOffline(node)
+		if oldNode != nil {
+			s.alerts.Evaluate(oldNode, updatedNode)
+		}
 		m, err := updatedNode.Convert()
 		if err != nil {
 			entry.WithError(err).Warnf("conver miner %d to protobuf message", node.ID)
 			return err
 		}
-		if b, err := proto.Marshal(m); err != nil {
-			entry.WithError(err).Errorf("marshal miner %d failed", updatedNode.ID)
-		} else {
-			mqcli.Publish(topic, b)
-			entry.Debugf("publishing information of miner %d", updatedNode.ID)
-		}
+		s.publish(m)
+		entry.Debugf("publishing information of miner %d", updatedNode.ID)
 	}
 	return nil
 }
 
-func (s *Service) auth(cred *msg.AuthReq) bool {
-	entry := log.WithFields(log.Fields{Function: "auth"})
-	signMsg := new(pb.SignMessage)
-	err := proto.Unmarshal(cred.Credential, signMsg)
+//rejectStale record receive time and stable statistics carried in cond for a report older than the stored one, used
+//space of the SN is recorded only if the report is not older than the one setting it, the rejection is counted in
+//database so that the count is shared by all instances and kept after restarting
+func (s *Service) rejectStale(snID int32, node *Node, cond bson.M, uspaceKey string) error {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "rejectStale", MinerID: node.ID, SNID: snID})
+	entry.Debugf("stale report of miner %d from SN%d rejected, timestamp: %d", node.ID, snID, node.Timestamp)
+	_, err := s.mongoCli.Database(MinerTrackerDB).Collection(StaleReportTab).UpdateOne(context.Background(), bson.M{"_id": fmt.Sprintf("sn%d", snID)}, bson.M{"$inc": bson.M{"count": int64(1)}}, options.Update().SetUpsert(true))
 	if err != nil {
-		entry.WithError(err).Error("decoding SignMessage")
-		return false
+		entry.WithError(err).Warnf("counting stale report of SN%d", snID)
 	}
-	collection := s.mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
-	auth := new(Auth)
-	err = collection.FindOne(context.Background(), bson.M{"_id": signMsg.AccountName}).Decode(auth)
+	set := bson.M{}
+	for k, v := range cond {
+		if k == "stableStat" || strings.HasPrefix(k, "receiveTimes.") {
+			set[k] = bson.M{"$literal": v}
+		}
+	}
+	//uspaces key is updated by its owner SN only, and reports of other SNs may be newer than the one setting it
+	if v, ok := node.Uspaces[uspaceKey]; ok {
+		usedSpace := fmt.Sprintf("uspaces.%s", uspaceKey)
+		usedTime := fmt.Sprintf("uspaceTimes.%s", uspaceKey)
+		newer := bson.M{"$gt": bson.A{"$" + usedTime, node.Timestamp}}
+		set[usedSpace] = bson.M{"$cond": bson.A{newer, "$" + usedSpace, bson.M{"$literal": v}}}
+		set[usedTime] = bson.M{"$cond": bson.A{newer, "$" + usedTime, node.Timestamp}}
+	}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: set}}, uspaceTotalStage}
+	_, err = s.mongoCli.Database(MinerTrackerDB).Collection(NodeTab).UpdateOne(context.Background(), bson.M{"_id": node.ID}, pipeline)
 	if err != nil {
-		entry.WithError(err).Errorf("decoding Auth record ofr account: %s", signMsg.AccountName)
-		return false
+		entry.WithError(err).Warnf("updating stable statistics of miner %d", node.ID)
+		return err
 	}
+	return nil
+}
+
+func (s *Service) uspaceKey(snID int32) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if key, ok := s.uspaceKeys[snID]; ok {
+		return key
+	}
+	return fmt.Sprintf("sn%d", snID)
+}
+
+//uspaceTotalStage stage of update pipeline setting uspaceTotal to sum of uspaces, so that the total is computed in
+//the same write as uspaces and never falls behind it
+var uspaceTotalStage = bson.D{{Key: "$set", Value: bson.M{"uspaceTotal": bson.M{"$sum": bson.M{"$map": bson.M{
+	"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$uspaces", bson.M{}}}},
+	"in":    "$$this.v",
+}}}}}}
+
+//literalSet stage of update pipeline setting fields to values as is, values are wrapped by $literal so that strings
+//starting with $ are not taken as field paths
+func literalSet(set bson.M) bson.D {
+	doc := bson.M{}
+	for k, v := range set {
+		doc[k] = bson.M{"$literal": v}
+	}
+	return bson.D{{Key: "$set", Value: doc}}
+}
 
-	if ytcrypto.Verify(auth.PublicKey, signMsg.Data, signMsg.Signature) {
-		return true
+//StaleReports returns count of stale reports rejected by all instances of each SN, keyed by sn<SN ID>
+func (s *Service) StaleReports(ctx context.Context) (map[string]int64, error) {
+	cur, err := s.mongoCli.Database(MinerTrackerDB).Collection(StaleReportTab).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
 	}
-	return false
+	defer cur.Close(ctx)
+	counts := make(map[string]int64)
+	for cur.Next(ctx) {
+		item := new(struct {
+			ID    string `bson:"_id"`
+			Count int64  `bson:"count"`
+		})
+		err := cur.Decode(item)
+		if err != nil {
+			return nil, err
+		}
+		counts[item.ID] = item.Count
+	}
+	return counts, cur.Err()
 }
 
 //Send one message to another client
@@ -220,52 +478,3 @@ func getRandomString(l int) string {
 	}
 	return string(result)
 }
-
-func refreshAuth(api *eos.API, mongoCli *mongo.Client) error {
-	entry := log.WithFields(log.Fields{Function: "refreshAuth"})
-	collection := mongoCli.Database(MinerTrackerDB).Collection(AuthTab)
-	cur, err := collection.Find(context.Background(), bson.M{})
-	if err != nil {
-		entry.WithError(err).Error("traversaling auth record failed")
-		return err
-	}
-	for cur.Next(context.Background()) {
-		auth := new(Auth)
-		err := cur.Decode(auth)
-		if err != nil {
-			entry.WithError(err).Error("decoding auth failed")
-			continue
-		}
-		pubkey, err := getPublicKey(api, auth.Account, "active")
-		if err != nil {
-			entry.WithField(AccountName, auth.Account).WithError(err).Error("fetching public key failed")
-			continue
-		}
-		if strings.HasPrefix(pubkey, "YTA") || strings.HasPrefix(pubkey, "EOS") {
-			pubkey = string(pubkey[3:])
-		}
-		if pubkey != auth.PublicKey {
-			_, err := collection.UpdateOne(context.Background(), bson.M{"_id": auth.Account}, bson.M{"$set": bson.M{"publickey": pubkey}})
-			if err != nil {
-				entry.WithField(AccountName, auth.Account).WithError(err).Error("update public key failed")
-			}
-		}
-
-	}
-	cur.Close(context.Background())
-	entry.Info("refreshed Auth table")
-	return nil
-}
-
-func getPublicKey(api *eos.API, accountName string, perm string) (string, error) {
-	accountResp, err := api.GetAccount(eos.AN(accountName))
-	if err != nil {
-		return "", fmt.Errorf("get account info: %s", err)
-	}
-	for _, p := range accountResp.Permissions {
-		if p.PermName == perm {
-			return p.RequiredAuth.Keys[0].PublicKey.String(), nil
-		}
-	}
-	return "", fmt.Errorf("no key found")
-}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aurawing/eos-go"
@@ -23,38 +24,94 @@ import (
 
 //MinerTracker miner tracker
 type MinerTracker struct {
-	server    *echo.Echo
-	dbCli     *mongo.Client
-	httpCli   *http.Client
-	minerStat *MinerStatConfig
-	params    *MiscConfig
+	server     *echo.Echo
+	dbCli      *mongo.Client
+	eosAPI     *eos.API
+	syncSvc    *Service
+	httpCli    *http.Client
+	replicaSet bool
+	configLock sync.RWMutex
+	minerStat  *MinerStatConfig
+	params     *MiscConfig
+	trackLock  sync.Mutex
+	trackCtx   context.Context
+	trackToken int64
+	trackers   map[int32]*statWorker
+	elector    *LeaderElector
+	alerts     *AlertManager
+	offline    *OfflineConfig
+	reconcile  *ReconcileConfig
 }
 
 //New create a new miner tracker instance
-func New(mongoDBURL, eosURL string, mqconf *AuraMQConfig, msConfig *MinerStatConfig, miscconf *MiscConfig) (*MinerTracker, error) {
+func New(mongoDBURL, eosURL string, mqconf *AuraMQConfig, msConfig *MinerStatConfig, alertConf *AlertConfig, offlineConf *OfflineConfig, reconcileConf *ReconcileConfig, indexes []*IndexSpec, miscconf *MiscConfig) (*MinerTracker, error) {
 	entry := log.WithFields(log.Fields{Function: "New"})
+	if err := CheckSNEndpoints(msConfig.AllSyncURLs); err != nil {
+		entry.WithError(err).Error("checking sync URLs failed")
+		return nil, err
+	}
+	if err := CheckSNEndpoints(mqconf.ClientConfig.AllSNURLs); err != nil {
+		entry.WithError(err).Error("checking MQ URLs failed")
+		return nil, err
+	}
 	dbClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoDBURL))
 	if err != nil {
 		entry.WithError(err).Errorf("creating mongo DB client failed: %s", mongoDBURL)
 		return nil, err
 	}
 	entry.Infof("mongoDB connected: %s", mongoDBURL)
+	replicaSet, err := isReplicaSet(context.Background(), dbClient)
+	if err != nil {
+		entry.WithError(err).Warn("detecting replica set of mongoDB failed, miner logs will be applied idempotently")
+	} else if replicaSet {
+		entry.Info("mongoDB is running as replica set, miner logs will be applied in transaction")
+	}
+	err = MigrateTrackProgress(context.Background(), dbClient, msConfig.AllSyncURLs)
+	if err != nil {
+		entry.WithError(err).Error("migrating tracking progress failed")
+		return nil, err
+	}
+	if miscconf.AutoMigrate {
+		_, err = Migrate(context.Background(), dbClient, LatestSchemaVersion())
+		if err != nil {
+			entry.WithError(err).Error("migrating schema failed")
+			return nil, err
+		}
+	} else if pending, err := PendingMigrations(context.Background(), dbClient); err == nil && len(pending) > 0 {
+		entry.Warnf("%d schema migrations are pending, run migrate command to apply them", len(pending))
+	}
+	err = CreateUpdateLog(context.Background(), dbClient, mqconf.Replay)
+	if err != nil {
+		entry.WithError(err).Error("creating update log failed")
+		return nil, err
+	}
+	err = EnsureIndexes(context.Background(), dbClient, indexes)
+	if err != nil {
+		entry.WithError(err).Warn("ensuring indexes failed, queries may be slow")
+	}
 	eosAPI := eos.New(eosURL)
 	entry.Infof("EOS server connected: %s", eosURL)
-	_, err = StartSync(eosAPI, dbClient, mqconf.ServerConfig, mqconf.ClientConfig, miscconf)
+	elector := NewLeaderElector(dbClient, miscconf.InstanceID, time.Duration(miscconf.LeaseTTL)*time.Second)
+	alerts := NewAlertManager(dbClient, elector.InstanceID(), alertConf)
+	syncService, err := StartSync(eosAPI, dbClient, mqconf.ServerConfig, mqconf.ClientConfig, mqconf.Federation, mqconf.Snapshot, mqconf.Replay, offlineConf, miscconf, elector, alerts)
 	if err != nil {
 		entry.WithError(err).Error("creating MQ service failed")
 		return nil, err
 	}
 	entry.Info("sync service started")
 	server := echo.New()
-	return &MinerTracker{server: server, dbCli: dbClient, httpCli: &http.Client{}, minerStat: msConfig, params: miscconf}, nil
+	return &MinerTracker{server: server, dbCli: dbClient, eosAPI: eosAPI, syncSvc: syncService, httpCli: &http.Client{}, minerStat: msConfig, params: miscconf, replicaSet: replicaSet, trackers: make(map[int32]*statWorker), elector: elector, alerts: alerts, offline: offlineConf, reconcile: reconcileConf}, nil
+}
+
+//RunElection take part in leader election, miner logs are tracked only when current instance is leader
+func (tracker *MinerTracker) RunElection(ctx context.Context) {
+	tracker.elector.Run(ctx, tracker.TrackingStat)
 }
 
 //Start HTTP server
 func (tracker *MinerTracker) Start(bindAddr string) error {
-	entry := log.WithFields(log.Fields{Function: "Start"})
-	tracker.server.Use(middleware.Logger())
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "Start"})
+	tracker.server.Use(requestLogger)
 	tracker.server.Use(middleware.Recover())
 	tracker.server.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level: 5,
@@ -62,6 +119,22 @@ func (tracker *MinerTracker) Start(bindAddr string) error {
 	tracker.server.POST("/query", tracker.QueryHandler)
 	tracker.server.POST("/stablestat/reset", tracker.ResetHandler)
 	tracker.server.POST("/stablestat/refresh", tracker.RefreshHandler)
+	tracker.server.GET("/status", tracker.StatusHandler)
+	tracker.server.GET("/offline", tracker.OfflineHandler)
+	tracker.server.GET("/lag", tracker.LagHandler)
+	tracker.server.GET("/uspaces/check", tracker.UspaceCheckHandler)
+	tracker.server.GET("/uspaces/reconcile", tracker.ReconcileHandler)
+	if tracker.miscConfig().AdminToken != "" {
+		admin := tracker.server.Group("/admin", middleware.KeyAuth(tracker.adminValidator))
+		admin.GET("/auth", tracker.ListAuthHandler)
+		admin.POST("/auth/:account", tracker.AddAuthHandler)
+		admin.DELETE("/auth/:account", tracker.RemoveAuthHandler)
+		admin.POST("/auth/:account/refresh", tracker.RefreshAuthHandler)
+		//refreshing all accounts is not under /auth so that it cannot clash with an account named refresh
+		admin.POST("/auth-cache/refresh", tracker.RefreshAuthHandler)
+	} else {
+		entry.Info("admin token is not set, admin API is disabled")
+	}
 	tracker.server.Server.Addr = bindAddr
 	err := graceful.ListenAndServe(tracker.server.Server, 5*time.Second)
 	if err != nil {
@@ -72,8 +145,12 @@ func (tracker *MinerTracker) Start(bindAddr string) error {
 
 //RefreshHandler refresh ratio of stable statictics
 func (tracker *MinerTracker) RefreshHandler(c echo.Context) error {
-	entry := log.WithFields(log.Fields{Function: "RefreshHandler"})
-	cond := bson.M{}
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "RefreshHandler"})
+	if !tracker.elector.IsLeader() {
+		return c.String(http.StatusServiceUnavailable, "stable statistics can only be refreshed by leader")
+	}
+	//miners without stable statistics are skipped until schema migration backfills them
+	cond := bson.M{"stableStat": bson.M{"$ne": nil}}
 	idstr := c.QueryParam("id")
 	if idstr != "" {
 		id, err := strconv.Atoi(idstr)
@@ -81,7 +158,7 @@ func (tracker *MinerTracker) RefreshHandler(c echo.Context) error {
 			entry.WithError(err).Errorf("invalid param %s", idstr)
 			return c.String(http.StatusInternalServerError, err.Error())
 		}
-		cond = bson.M{"_id": id}
+		cond["_id"] = id
 	}
 	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
 	cur, err := collection.Find(context.Background(), cond)
@@ -103,7 +180,7 @@ func (tracker *MinerTracker) RefreshHandler(c echo.Context) error {
 		}
 		_, err = collection.UpdateOne(context.Background(), bson.M{"_id": node.ID}, bson.M{"$set": bson.M{"stableStat.ratio": ratio}})
 		if err != nil {
-			entry.WithError(err).Error("update ratio of miner %d", node.ID)
+			entry.WithError(err).Errorf("update ratio of miner %d", node.ID)
 			continue
 		}
 	}
@@ -112,7 +189,7 @@ func (tracker *MinerTracker) RefreshHandler(c echo.Context) error {
 
 //ResetHandler reset stable statistics
 func (tracker *MinerTracker) ResetHandler(c echo.Context) error {
-	entry := log.WithFields(log.Fields{Function: "ResetHandler"})
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "ResetHandler"})
 	cond := bson.M{}
 	idstr := c.QueryParam("id")
 	if idstr != "" {
@@ -135,6 +212,32 @@ func (tracker *MinerTracker) ResetHandler(c echo.Context) error {
 	return c.String(http.StatusOK, "success")
 }
 
+//Status status of tracker instance
+type Status struct {
+	InstanceID string `json:"instanceID"`
+	Leader     bool   `json:"leader"`
+	Token      int64  `json:"token"`
+	Lease      *Lease `json:"lease"`
+	//StaleReports count of stale reports rejected by all instances of each SN
+	StaleReports map[string]int64 `json:"staleReports"`
+}
+
+//StatusHandler show leadership of current instance, the current lease and count of rejected stale reports
+func (tracker *MinerTracker) StatusHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "StatusHandler"})
+	lease, err := tracker.elector.CurrentLease(context.Background())
+	if err != nil {
+		entry.WithError(err).Error("reading lease")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	stale, err := tracker.syncSvc.StaleReports(context.Background())
+	if err != nil {
+		entry.WithError(err).Error("reading count of stale reports")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, &Status{InstanceID: tracker.elector.InstanceID(), Leader: tracker.elector.IsLeader(), Token: tracker.elector.Token(), Lease: lease, StaleReports: stale})
+}
+
 //FilterMiners find miners by condition
 func (tracker *MinerTracker) FilterMiners(q bson.M, sortparam string, ascparam bool, limitparam int64) ([]*Node, error) {
 	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
@@ -168,7 +271,7 @@ func (tracker *MinerTracker) FilterMiners(q bson.M, sortparam string, ascparam b
 
 //QueryHandler process miner info query
 func (tracker *MinerTracker) QueryHandler(c echo.Context) error {
-	entry := log.WithFields(log.Fields{Function: "QueryHandler"})
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "QueryHandler"})
 	var reader = io.Reader(c.Request().Body)
 	if strings.Contains(c.Request().Header.Get("Content-Encoding"), "gzip") {
 		gbuf, err := gzip.NewReader(reader)
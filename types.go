@@ -1,7 +1,7 @@
 package yttracker
 
 import (
-	"encoding/json"
+	"reflect"
 
 	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,90 +14,156 @@ const (
 	MinerID = "minerID"
 	//AccountName tag
 	AccountName = "account"
+	//SNID tag
+	SNID = "sn"
+	//ClientID tag
+	ClientID = "clientID"
+	//InstanceID tag
+	InstanceID = "instance"
+	//Component tag
+	Component = "component"
+)
+
+//log components, level of each component can be set separately
+const (
+	ComponentSync     = "sync"
+	ComponentTracking = "tracking"
+	ComponentHTTP     = "http"
+	ComponentAuth     = "auth"
+	ComponentAlert    = "alert"
 )
 
 //Auth crenditial info
 type Auth struct {
 	//Account name in BP
-	Account string `bson:"_id"`
-	//PublicKey public key of account
-	PublicKey string `bson:"publickey"`
+	Account string `bson:"_id" json:"account"`
+	//PublicKey first public key of permission, kept for compatibility
+	PublicKey string `bson:"publickey" json:"publickey"`
+	//Permission name of permission used for authenticating, active permission is used if empty
+	Permission string `bson:"permission" json:"permission"`
+	//Threshold threshold of permission
+	Threshold uint32 `bson:"threshold" json:"threshold"`
+	//Keys all public keys of permission
+	Keys []*AuthKey `bson:"keys" json:"keys"`
+	//LastLogin timestamp of last successful login of MQ client
+	LastLogin int64 `bson:"lastLogin" json:"lastLogin"`
+	//LastClientID ID of MQ client of last successful login
+	LastClientID string `bson:"lastClientID" json:"lastClientID"`
 }
 
-// Node instance
+//AuthKey public key with weight in permission of account
+type AuthKey struct {
+	PublicKey string `bson:"publickey" json:"publickey"`
+	Weight    uint32 `bson:"weight" json:"weight"`
+}
+
+//DefaultPermission permission used for authenticating if not specified
+const DefaultPermission = "active"
+
+// Node instance, bson tag of each field is its name in database and JSON output, pb tag is the name of
+// corresponding field in NodeMsg, see nodeMapping
 type Node struct {
 	//data node index
-	ID int32 `bson:"_id" json:"_id"`
+	ID int32 `bson:"_id" json:"_id" pb:"ID"`
 	//data node ID, generated from PubKey
-	NodeID string `bson:"nodeid" json:"nodeid"`
+	NodeID string `bson:"nodeid" json:"nodeid" pb:"NodeID"`
 	//public key of data node
-	PubKey string `bson:"pubkey" json:"pubkey"`
+	PubKey string `bson:"pubkey" json:"pubkey" pb:"PubKey"`
 	//owner account of this miner
-	Owner string `bson:"owner" json:"owner"`
+	Owner string `bson:"owner" json:"owner" pb:"Owner"`
 	//profit account of this miner
-	ProfitAcc string `bson:"profitAcc" json:"profitAcc"`
+	ProfitAcc string `bson:"profitAcc" json:"profitAcc" pb:"ProfitAcc"`
 	//ID of associated miner pool
-	PoolID string `bson:"poolID" json:"poolID"`
+	PoolID string `bson:"poolID" json:"poolID" pb:"PoolID"`
 	//Owner of associated miner pool
-	PoolOwner string `bson:"poolOwner" json:"poolOwner"`
+	PoolOwner string `bson:"poolOwner" json:"poolOwner" pb:"PoolOwner"`
 	//quota allocated by associated miner pool
-	Quota int64 `bson:"quota" json:"quota"`
+	Quota int64 `bson:"quota" json:"quota" pb:"Quota"`
 	//listening addresses of data node
-	Addrs []string `bson:"addrs" json:"addrs"`
+	Addrs []string `bson:"addrs" json:"addrs" pb:"Addrs"`
 	//CPU usage of data node
-	CPU int32 `bson:"cpu" json:"cpu"`
+	CPU int32 `bson:"cpu" json:"cpu" pb:"CPU"`
 	//memory usage of data node
-	Memory int32 `bson:"memory" json:"memory"`
+	Memory int32 `bson:"memory" json:"memory" pb:"Memory"`
 	//bandwidth usage of data node
-	Bandwidth int32 `bson:"bandwidth" json:"bandwidth"`
+	Bandwidth int32 `bson:"bandwidth" json:"bandwidth" pb:"Bandwidth"`
 	//max space of data node
-	MaxDataSpace int64 `bson:"maxDataSpace" json:"maxDataSpace"`
+	MaxDataSpace int64 `bson:"maxDataSpace" json:"maxDataSpace" pb:"MaxDataSpace"`
 	//space assigned to YTFS
-	AssignedSpace int64 `bson:"assignedSpace" json:"assignedSpace"`
+	AssignedSpace int64 `bson:"assignedSpace" json:"assignedSpace" pb:"AssignedSpace"`
 	//pre-allocated space of data node
-	ProductiveSpace int64 `bson:"productiveSpace" json:"productiveSpace"`
+	ProductiveSpace int64 `bson:"productiveSpace" json:"productiveSpace" pb:"ProductiveSpace"`
 	//used space of data node
-	UsedSpace int64 `bson:"usedSpace" json:"usedSpace"`
+	UsedSpace int64 `bson:"usedSpace" json:"usedSpace" pb:"UsedSpace"`
 	//used spaces on each SN
-	Uspaces map[string]int64 `bson:"uspaces" json:"uspaces"`
+	Uspaces map[string]int64 `bson:"uspaces" json:"uspaces" pb:"Uspaces,merge"`
 	//weight for allocate data node
-	Weight float64 `bson:"weight" json:"weight"`
+	Weight float64 `bson:"weight" json:"weight" pb:"Weight"`
 	//Is node valid
-	Valid int32 `bson:"valid" json:"valid"`
+	Valid int32 `bson:"valid" json:"valid" pb:"Valid"`
 	//Is relay node
-	Relay int32 `bson:"relay" json:"relay"`
+	Relay int32 `bson:"relay" json:"relay" pb:"Relay"`
 	//status code: 0 - registered 1 - active
-	Status int32 `bson:"status" json:"status"`
+	Status int32 `bson:"status" json:"status" pb:"Status"`
 	//timestamp of status updating operation
-	Timestamp int64 `bson:"timestamp" json:"timestamp"`
+	Timestamp int64 `bson:"timestamp" json:"timestamp" pb:"Timestamp"`
 	//version number of miner
-	Version int32 `bson:"version" json:"version"`
+	Version int32 `bson:"version" json:"version" pb:"Version"`
 	//Rebuilding if node is under rebuilding
-	Rebuilding int32 `bson:"rebuilding" json:"rebuilding"`
+	Rebuilding int32 `bson:"rebuilding" json:"rebuilding" pb:"Rebuilding"`
 	//RealSpace real space of miner
-	RealSpace int64 `bson:"realSpace" json:"realSpace"`
+	RealSpace int64 `bson:"realSpace" json:"realSpace" pb:"RealSpace"`
 	//Tx
-	Tx int64 `bson:"tx" json:"tx"`
+	Tx int64 `bson:"tx" json:"tx" pb:"Tx"`
 	//Rx
-	Rx int64 `bson:"rx" json:"rx"`
+	Rx int64 `bson:"rx" json:"rx" pb:"Rx"`
 	//Other
-	Other bson.A `bson:"other" json:"other"`
+	Other bson.A `bson:"other" json:"other" pb:"Ext,merge"`
 	//ManualWeight
-	ManualWeight int32 `bson:"manualWeight" json:"manualWeight"`
+	ManualWeight int32 `bson:"manualWeight" json:"manualWeight" pb:"ManualWeight"`
 	//Unreadable
-	Unreadable bool `bson:"unreadable" json:"unreadable"`
+	Unreadable bool `bson:"unreadable" json:"unreadable" pb:"Unreadable"`
 	//HashID
-	HashID string `bson:"hashID"`
+	HashID string `bson:"hashID" json:"hashID" pb:"Hash"`
 	//BlCount
-	BlCount int32 `bson:"blCount"`
+	BlCount int32 `bson:"blCount" json:"blCount" pb:"BlCount"`
 	//Filing
-	Filing bool `bson:"filing"`
+	Filing bool `bson:"filing" json:"filing" pb:"Filing"`
 	//AllocatedSpace
-	AllocatedSpace int64 `bson:"allocatedSpace"`
+	AllocatedSpace int64 `bson:"allocatedSpace" json:"allocatedSpace" pb:"AllocatedSpace"`
 	//StableStat
 	StableStat *StableStatistics `bson:"stableStat" json:"stableStat"`
 	//regtime
 	RegTime int64 `bson:"regtime" json:"regtime"`
+	//LastLogID ID of the last miner log applied to this miner
+	LastLogID int64 `bson:"lastLogID" json:"lastLogID"`
+	//OfflineSince time when miner was detected offline, 0 if miner is online
+	OfflineSince int64 `bson:"offlineSince" json:"offlineSince"`
+	//UspaceTimes timestamp of the report which set each key of uspaces
+	UspaceTimes map[string]int64 `bson:"uspaceTimes" json:"uspaceTimes"`
+	//LastReceived time(millisecond) when tracker received the last report of miner
+	LastReceived int64 `bson:"lastReceived" json:"lastReceived" pb:"LastReceived"`
+	//LastSource ID of SN from which the last report was received
+	LastSource int32 `bson:"lastSource" json:"lastSource" pb:"LastSource"`
+	//ReceiveTimes time(millisecond) of the last report received from each SN, keyed by sn<SN ID>
+	ReceiveTimes map[string]int64 `bson:"receiveTimes" json:"receiveTimes" pb:"ReceiveTimes,merge"`
+	//UspaceTotal sum of used spaces on all SNs
+	UspaceTotal int64 `bson:"uspaceTotal" json:"uspaceTotal" pb:"UspaceTotal,merge"`
+	//Ext extension fields reported by SN which are not defined in NodeMsg, keyed by field name
+	Ext bson.M `bson:"ext" json:"ext" pb:"Extensions,merge"`
+	//Unrecognized encoded NodeMsg fields unknown to this version of tracker, it is stored because NodeMsg published
+	//to subscribers is converted from the stored miner, so fields sent by newer SN would be lost otherwise, it is
+	//opaque protobuf encoding and hidden from JSON output
+	Unrecognized []byte `bson:"unrecognized" json:"-" pb:"XXX_unrecognized"`
+}
+
+//UspacesSum returns sum of used spaces on all SNs
+func (node *Node) UspacesSum() int64 {
+	var sum int64
+	for _, v := range node.Uspaces {
+		sum += v
+	}
+	return sum
 }
 
 //StableStatistics struct
@@ -107,7 +173,9 @@ type StableStatistics struct {
 	Ratio     float32 `bson:"ratio" json:"ratio"`
 }
 
-//NewNode create a node struct
+//NewNode create a node struct, fields added after allocatedSpace are not covered
+//
+//Deprecated: use Node literal instead
 func NewNode(id int32, nodeid string, pubkey string, owner string, profitAcc string, poolID string, poolOwner string, quota int64, addrs []string, cpu int32, memory int32, bandwidth int32, maxDataSpace int64, assignedSpace int64, productiveSpace int64, usedSpace int64, weight float64, valid int32, relay int32, status int32, timestamp int64, version int32, rebuilding int32, realSpace int64, tx int64, rx int64, other bson.A, manualWeight int32, unreadable bool, hashID string, blCount int32, filing bool, allocatedSpace int64) *Node {
 	return &Node{ID: id, NodeID: nodeid, PubKey: pubkey, Owner: owner, ProfitAcc: profitAcc, PoolID: poolID, PoolOwner: poolOwner, Quota: quota, Addrs: addrs, CPU: cpu, Memory: memory, Bandwidth: bandwidth, MaxDataSpace: maxDataSpace, AssignedSpace: assignedSpace, ProductiveSpace: productiveSpace, UsedSpace: usedSpace, Weight: weight, Valid: valid, Relay: relay, Status: status, Timestamp: timestamp, Version: version, Rebuilding: rebuilding, RealSpace: realSpace, Tx: tx, Rx: rx, Other: other, ManualWeight: manualWeight, Unreadable: unreadable, HashID: hashID, BlCount: blCount, Filing: filing, AllocatedSpace: allocatedSpace}
 }
@@ -120,98 +188,39 @@ var (
 	TrackProgressTab = "TrackProgress"
 )
 
-// Convert convert Node strcut to NodeMsg
+// Convert convert Node strcut to NodeMsg, fields are copied according to nodeMappings
 func (node *Node) Convert() (*pb.NodeMsg, error) {
-	ext := ""
-	if node.Other != nil {
-		b, err := json.Marshal(node.Other)
-		if err != nil {
-			return nil, err
+	msg := new(pb.NodeMsg)
+	nv, mv := reflect.ValueOf(node).Elem(), reflect.ValueOf(msg).Elem()
+	for _, m := range nodeMappings {
+		if m.pb == "" {
+			continue
 		}
-		ext = string(b)
+		if conv, ok := nodeConverters[m.field]; ok {
+			if err := conv.toPB(node, msg); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		mv.Field(m.pbIndex).Set(nv.Field(m.index))
 	}
-	return &pb.NodeMsg{
-		ID:              node.ID,
-		NodeID:          node.NodeID,
-		PubKey:          node.PubKey,
-		Owner:           node.Owner,
-		ProfitAcc:       node.ProfitAcc,
-		PoolID:          node.PoolID,
-		PoolOwner:       node.PoolOwner,
-		Quota:           node.Quota,
-		Addrs:           node.Addrs,
-		CPU:             node.CPU,
-		Memory:          node.Memory,
-		Bandwidth:       node.Bandwidth,
-		MaxDataSpace:    node.MaxDataSpace,
-		AssignedSpace:   node.AssignedSpace,
-		ProductiveSpace: node.ProductiveSpace,
-		UsedSpace:       node.UsedSpace,
-		Uspaces:         node.Uspaces,
-		Weight:          node.Weight,
-		Valid:           node.Valid,
-		Relay:           node.Relay,
-		Status:          node.Status,
-		Timestamp:       node.Timestamp,
-		Version:         node.Version,
-		Rebuilding:      node.Rebuilding,
-		RealSpace:       node.RealSpace,
-		Tx:              node.Tx,
-		Rx:              node.Rx,
-		Ext:             ext,
-		ManualWeight:    node.ManualWeight,
-		Unreadable:      node.Unreadable,
-		Hash:            node.HashID,
-		BlCount:         node.BlCount,
-		Filing:          node.Filing,
-		AllocatedSpace:  node.AllocatedSpace,
-	}, nil
+	return msg, nil
 }
 
-// Fillby convert NodeMsg to Node struct
+// Fillby convert NodeMsg to Node struct, fields are copied according to nodeMappings
 func (node *Node) Fillby(msg *pb.NodeMsg) error {
-	node.ID = msg.ID
-	node.NodeID = msg.NodeID
-	node.PubKey = msg.PubKey
-	node.Owner = msg.Owner
-	node.ProfitAcc = msg.ProfitAcc
-	node.PoolID = msg.PoolID
-	node.PoolOwner = msg.PoolOwner
-	node.Quota = msg.Quota
-	node.Addrs = msg.Addrs
-	node.CPU = msg.CPU
-	node.Memory = msg.Memory
-	node.Bandwidth = msg.Bandwidth
-	node.MaxDataSpace = msg.MaxDataSpace
-	node.AssignedSpace = msg.AssignedSpace
-	node.ProductiveSpace = msg.ProductiveSpace
-	node.UsedSpace = msg.UsedSpace
-	node.Uspaces = msg.Uspaces
-	node.Weight = msg.Weight
-	node.Valid = msg.Valid
-	node.Relay = msg.Relay
-	node.Status = msg.Status
-	node.Timestamp = msg.Timestamp
-	node.Version = msg.Version
-	node.Rebuilding = msg.Rebuilding
-	node.RealSpace = msg.RealSpace
-	node.Tx = msg.Tx
-	node.Rx = msg.Rx
-	other := bson.A{}
-	if msg.Ext != "" && msg.Ext[0] == '[' && msg.Ext[len(msg.Ext)-1] == ']' {
-		var bdoc interface{}
-		err := bson.UnmarshalExtJSON([]byte(msg.Ext), true, &bdoc)
-		if err != nil {
-			return err
+	nv, mv := reflect.ValueOf(node).Elem(), reflect.ValueOf(msg).Elem()
+	for _, m := range nodeMappings {
+		if m.pb == "" {
+			continue
+		}
+		if conv, ok := nodeConverters[m.field]; ok {
+			if err := conv.fromPB(node, msg); err != nil {
+				return err
+			}
+			continue
 		}
-		other, _ = bdoc.(bson.A)
+		nv.Field(m.index).Set(mv.Field(m.pbIndex))
 	}
-	node.Other = other
-	node.ManualWeight = msg.ManualWeight
-	node.Unreadable = msg.Unreadable
-	node.HashID = msg.Hash
-	node.BlCount = msg.BlCount
-	node.Filing = msg.Filing
-	node.AllocatedSpace = msg.AllocatedSpace
 	return nil
 }
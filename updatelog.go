@@ -0,0 +1,360 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aurawing/auramq/msg"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//relative collection names of update log
+var (
+	UpdateLogTab = "UpdateLog"
+	SequenceTab  = "Sequence"
+)
+
+//sequenceID ID of the counter document of update sequence numbers, which is shared by all tracker instances
+//connecting to the same database, so that sequence numbers are kept after restarting and comparable across instances
+const sequenceID = "update"
+
+//count of updates waiting to be numbered and published, publishing blocks if queue is full
+const updateQueueSize = 1024
+
+//max count of queued updates numbered and saved at a time
+const maxUpdateBatch = 100
+
+//error code returned by mongoDB when creating an existing collection
+const errCodeNamespaceExists = 48
+
+//UpdateLog one published miner update retained for replaying, Origin is the instance publishing it
+type UpdateLog struct {
+	Seq       int64  `bson:"seq"`
+	Origin    string `bson:"origin"`
+	Content   []byte `bson:"content"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+//updateLog assigns sequence numbers from the counter shared by all instances to updates published by current instance
+//and retains them in a capped collection, updates are queued and numbered, saved and published by one goroutine
+//so that they are published in order of sequence numbers without holding any lock during I/O
+type updateLog struct {
+	db         *mongo.Database
+	instanceID string
+	queue      chan *pb.NodeMsg
+	lock       sync.Mutex
+	lost       int64
+	unnumbered bool
+}
+
+//sequenceCounter counter document of update sequence numbers, Lost is the last sequence number of updates which
+//were published but not retained by any instance, resuming from before it requires resync
+type sequenceCounter struct {
+	Seq  int64 `bson:"seq"`
+	Lost int64 `bson:"lost"`
+}
+
+//CreateUpdateLog create capped collection of update log if not exists, it must be called before indexes are ensured,
+//otherwise a normal collection is created by index of update log
+func CreateUpdateLog(ctx context.Context, cli *mongo.Client, conf *ReplayConfig) error {
+	cmd := bson.D{{Key: "create", Value: UpdateLogTab}, {Key: "capped", Value: true}, {Key: "size", Value: int64(conf.MaxSize) * 1024 * 1024}}
+	if conf.MaxCount > 0 {
+		cmd = append(cmd, bson.E{Key: "max", Value: conf.MaxCount})
+	}
+	err := cli.Database(MinerTrackerDB).RunCommand(ctx, cmd).Err()
+	if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == errCodeNamespaceExists {
+		err = nil
+	}
+	return err
+}
+
+//newUpdateLog create update log of instance in db
+func newUpdateLog(db *mongo.Database, instanceID string) *updateLog {
+	return &updateLog{db: db, instanceID: instanceID, queue: make(chan *pb.NodeMsg, updateQueueSize)}
+}
+
+//reserve reserve count sequence numbers from the shared counter, returns the last one, updates lost by current instance
+//are recorded in the counter at the same time
+func (l *updateLog) reserve(ctx context.Context, count int64) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	counter := new(sequenceCounter)
+	err := l.db.Collection(SequenceTab).FindOneAndUpdate(ctx, bson.M{"_id": sequenceID}, bson.M{"$inc": bson.M{"seq": count}, "$max": bson.M{"lost": l.lostSeq()}}, opts).Decode(counter)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+//lastSeq returns the last sequence number reserved by any instance, 0 if there is none, and the last sequence number
+//of updates lost, miners are saved to database before their updates are numbered, and updates are saved to update log
+//before being published
+func (l *updateLog) lastSeq(ctx context.Context) (int64, int64, error) {
+	counter := new(sequenceCounter)
+	err := l.db.Collection(SequenceTab).FindOne(ctx, bson.M{"_id": sequenceID}).Decode(counter)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, 0, err
+	}
+	lost := l.lostSeq()
+	if counter.Lost > lost {
+		lost = counter.Lost
+	}
+	return counter.Seq, lost, nil
+}
+
+//firstSeq returns sequence number of the oldest update retained, 0 if there is none
+func (l *updateLog) firstSeq(ctx context.Context) (int64, error) {
+	first := new(UpdateLog)
+	err := l.db.Collection(UpdateLogTab).FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"seq": 1})).Decode(first)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return first.Seq, nil
+}
+
+//lostSeq returns the last sequence number of updates published by current instance which were not retained
+func (l *updateLog) lostSeq() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.lost
+}
+
+//markLost record that updates are published without being retained, and subscribers resuming from before seq must
+//resync, if unnumbered is set, updates are published without sequence numbers, and subscribers resuming from before
+//the next sequence number reserved must resync
+func (l *updateLog) markLost(seq int64, unnumbered bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if seq > l.lost {
+		l.lost = seq
+	}
+	l.unnumbered = l.unnumbered || unnumbered
+}
+
+//append number updates in order and save them, returns encoded updates, which is nil for update failed to be encoded,
+//updates are not numbered if sequence numbers cannot be reserved
+func (l *updateLog) append(ctx context.Context, batch []*pb.NodeMsg) ([][]byte, error) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "append"})
+	last, err := l.reserve(ctx, int64(len(batch)))
+	if err != nil {
+		entry.WithError(err).Error("reserving sequence numbers, updates are published without sequence numbers")
+		l.markLost(0, true)
+		last = 0
+	} else {
+		l.lock.Lock()
+		if l.unnumbered {
+			l.unnumbered = false
+			l.lost = last - int64(len(batch)) + 1
+		}
+		l.lock.Unlock()
+	}
+	contents := make([][]byte, len(batch))
+	docs := make([]interface{}, 0, len(batch))
+	now := time.Now().Unix()
+	for i, m := range batch {
+		if last > 0 {
+			m.Seq = last - int64(len(batch)) + int64(i) + 1
+		}
+		content, err := proto.Marshal(m)
+		if err != nil {
+			entry.WithError(err).Errorf("marshal miner %d failed", m.ID)
+			continue
+		}
+		contents[i] = content
+		if m.Seq > 0 {
+			docs = append(docs, &UpdateLog{Seq: m.Seq, Origin: l.instanceID, Content: content, Timestamp: now})
+		}
+	}
+	if len(docs) == 0 {
+		return contents, nil
+	}
+	_, err = l.db.Collection(UpdateLogTab).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		l.markLost(last, false)
+		return contents, err
+	}
+	return contents, nil
+}
+
+//checkResume check whether updates after from can be replayed, returns the last sequence number to be replayed, or
+//the reason why requester has to resync
+func (l *updateLog) checkResume(ctx context.Context, from int64) (int64, string, error) {
+	last, lost, err := l.lastSeq(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	if from < 0 || from > last {
+		return 0, fmt.Sprintf("unknown sequence number %d, the last one is %d", from, last), nil
+	}
+	if from < lost {
+		return 0, fmt.Sprintf("update %d after %d was not retained", lost, from), nil
+	}
+	first, err := l.firstSeq(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	if from < last && (first == 0 || first > from+1) {
+		return 0, fmt.Sprintf("gap too old, updates after %d are no longer retained", from), nil
+	}
+	return last, "", nil
+}
+
+//find read retained updates of sequence numbers in (from, to] ordered by sequence number
+func (l *updateLog) find(ctx context.Context, from, to int64) (*mongo.Cursor, error) {
+	return l.db.Collection(UpdateLogTab).Find(ctx, bson.M{"seq": bson.M{"$gt": from, "$lte": to}}, options.Find().SetSort(bson.M{"seq": 1}))
+}
+
+//publish queue NodeMsg received from SN to be numbered and delivered to subscribers and peers
+func (s *Service) publish(m *pb.NodeMsg) {
+	s.updates.queue <- m
+}
+
+//runPublisher number and save queued updates in batches, then deliver them to local subscribers and peers in order
+func (s *Service) runPublisher() {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "runPublisher"})
+	for m := range s.updates.queue {
+		batch := []*pb.NodeMsg{m}
+	drain:
+		for len(batch) < maxUpdateBatch {
+			select {
+			case m := <-s.updates.queue:
+				batch = append(batch, m)
+			default:
+				break drain
+			}
+		}
+		//updates are published even if they cannot be retained, resuming from before them requires resync
+		contents, err := s.updates.append(context.Background(), batch)
+		if err != nil {
+			entry.WithError(err).Error("saving update log")
+		}
+		for i, m := range batch {
+			if contents[i] == nil {
+				continue
+			}
+			s.publishLocal(m, contents[i])
+			s.relay(contents[i], m.Seq)
+		}
+	}
+}
+
+//publishLocal deliver encoded NodeMsg to local subscribers of live topic, and of replay topic if it is numbered
+func (s *Service) publishLocal(m *pb.NodeMsg, content []byte) {
+	s.client.Publish(s.topic, content)
+	if m.Seq > 0 {
+		s.publishSeq(m)
+	}
+}
+
+//publishSeq publish NodeMsg with its sequence number to replay topic
+func (s *Service) publishSeq(m *pb.NodeMsg) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "publishSeq"})
+	b, err := proto.Marshal(&pb.SeqMsg{Seq: m.Seq, Node: m})
+	if err != nil {
+		entry.WithError(err).Error("encoding SeqMsg")
+		return
+	}
+	s.client.Publish(s.replayConf.Topic, b)
+}
+
+//handleResumeRequest decode request published to resume topic and start sending updates missed by requester
+func (s *Service) handleResumeRequest(m *msg.Message) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "handleResumeRequest", ClientID: m.GetSender()})
+	req := new(pb.ResumeReq)
+	err := proto.Unmarshal(m.Content, req)
+	if err != nil {
+		entry.WithError(err).Warn("decoding ResumeReq failed")
+		s.sendMessage(m.GetSender(), &pb.ResumeMsg{End: true, Error: fmt.Sprintf("invalid resume request: %s", err)})
+		return
+	}
+	s.startResume(m.GetSender(), req)
+}
+
+//startResume start sending updates missed by requester, request is rejected if too many requests are being processed
+func (s *Service) startResume(to string, req *pb.ResumeReq) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "startResume", ClientID: to})
+	select {
+	case s.requestSem <- struct{}{}:
+	default:
+		entry.Warn("too many resume requests")
+		s.sendMessage(to, &pb.ResumeMsg{RequestID: req.RequestID, End: true, Error: "too many resume requests, try again later"})
+		return
+	}
+	go func() {
+		defer func() { <-s.requestSem }()
+		s.sendMissed(to, req)
+	}()
+}
+
+//sendMissed send retained updates after sequence number of request to client in batches, followed by a message
+//marked as end, updates published by all instances are retained in the same collection, so requester can resume
+//from any instance, requester is asked to resync if some of the missed updates have been overwritten
+func (s *Service) sendMissed(to string, req *pb.ResumeReq) {
+	entry := log.WithFields(log.Fields{Component: ComponentSync, Function: "sendMissed", ClientID: to})
+	conf := s.replayConf
+	ctx := context.Background()
+	fail := func(err error) {
+		entry.WithError(err).Errorf("replaying updates of request %s failed", req.RequestID)
+		s.sendMessage(to, &pb.ResumeMsg{RequestID: req.RequestID, End: true, Error: err.Error()})
+	}
+	last, reason, err := s.updates.checkResume(ctx, req.From)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if reason != "" {
+		entry.Infof("resync required by request %s: %s", req.RequestID, reason)
+		s.sendMessage(to, &pb.ResumeMsg{RequestID: req.RequestID, End: true, ResyncRequired: true, Error: reason})
+		return
+	}
+	//updates numbered but not saved yet are published after being saved, so requester receives them as live messages
+	cur, err := s.updates.find(ctx, req.From, last)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer cur.Close(ctx)
+	lastSent := req.From
+	batch := make([]*pb.SeqMsg, 0, conf.BatchSize)
+	for cur.Next(ctx) {
+		item := new(UpdateLog)
+		err := cur.Decode(item)
+		if err != nil {
+			fail(err)
+			return
+		}
+		nodeMsg := new(pb.NodeMsg)
+		err = proto.Unmarshal(item.Content, nodeMsg)
+		if err != nil {
+			fail(err)
+			return
+		}
+		batch = append(batch, &pb.SeqMsg{Seq: item.Seq, Node: nodeMsg})
+		lastSent = item.Seq
+		if len(batch) < conf.BatchSize {
+			continue
+		}
+		if !s.sendMessage(to, &pb.ResumeMsg{RequestID: req.RequestID, Updates: batch}) {
+			entry.Errorf("replaying updates of request %s aborted", req.RequestID)
+			return
+		}
+		batch = make([]*pb.SeqMsg, 0, conf.BatchSize)
+	}
+	if err := cur.Err(); err != nil {
+		//retained updates may be overwritten while reading capped collection
+		fail(err)
+		return
+	}
+	if s.sendMessage(to, &pb.ResumeMsg{RequestID: req.RequestID, Updates: batch, End: true, LastSeq: lastSent}) {
+		entry.Infof("updates of request %s replayed: %d to %d", req.RequestID, req.From, lastSent)
+	}
+}
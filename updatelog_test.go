@@ -0,0 +1,72 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/yottachain/yotta-miner-tracker/pbtracker"
+)
+
+//TestResumeAcrossRestart subscriber resuming after tracker restarted with another instance ID must receive updates
+//published before and after restarting, and nothing it has received
+func TestResumeAcrossRestart(t *testing.T) {
+	collection, drop := testCollection(t)
+	defer drop()
+	ctx := context.Background()
+	db := collection.Database().Client().Database(fmt.Sprintf("yttracker_test_%d", time.Now().UnixNano()))
+	defer db.Drop(ctx)
+
+	before := newUpdateLog(db, "host-1")
+	if _, err := before.append(ctx, []*pb.NodeMsg{{ID: 1}, {ID: 2}, {ID: 3}}); err != nil {
+		t.Fatalf("appending updates before restarting: %s", err)
+	}
+	//subscriber disconnected after receiving update of miner 2
+	received := int64(2)
+
+	after := newUpdateLog(db, "host-2")
+	batch := []*pb.NodeMsg{{ID: 4}, {ID: 5}}
+	if _, err := after.append(ctx, batch); err != nil {
+		t.Fatalf("appending updates after restarting: %s", err)
+	}
+	if batch[0].Seq != 4 || batch[1].Seq != 5 {
+		t.Fatalf("sequence numbers after restarting: %d, %d, expected 4, 5", batch[0].Seq, batch[1].Seq)
+	}
+
+	last, reason, err := after.checkResume(ctx, received)
+	if err != nil {
+		t.Fatalf("checking resume: %s", err)
+	}
+	if reason != "" {
+		t.Fatalf("resync required: %s", reason)
+	}
+	cur, err := after.find(ctx, received, last)
+	if err != nil {
+		t.Fatalf("finding updates: %s", err)
+	}
+	defer cur.Close(ctx)
+	ids := make([]int32, 0)
+	for cur.Next(ctx) {
+		item := new(UpdateLog)
+		if err := cur.Decode(item); err != nil {
+			t.Fatalf("decoding update: %s", err)
+		}
+		m := new(pb.NodeMsg)
+		if err := proto.Unmarshal(item.Content, m); err != nil {
+			t.Fatalf("decoding NodeMsg: %s", err)
+		}
+		if m.Seq != item.Seq {
+			t.Fatalf("sequence number of miner %d is %d in content, %d in update log", m.ID, m.Seq, item.Seq)
+		}
+		ids = append(ids, m.ID)
+	}
+	if fmt.Sprint(ids) != "[3 4 5]" {
+		t.Fatalf("replayed miners: %v, expected [3 4 5]", ids)
+	}
+
+	if _, reason, err := after.checkResume(ctx, last+1); err != nil || reason == "" {
+		t.Fatalf("resuming from unknown sequence number is not rejected: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//collection names used by uspace correction
+var (
+	CheckPointTab = "CheckPoint"
+	EndPointTab   = "EndPoint"
+	CalcNodeTab   = "CalcNode"
+	TempNodeTab   = "TempNode"
+)
+
+//Shard shard record in SN
+type Shard struct {
+	ID      int64 `bson:"_id"`
+	NodeID  int32 `bson:"nodeId"`
+	NodeID2 int32 `bson:"nodeId2"`
+}
+
+//TempNode snapshot of used space of miner when correction starts
+type TempNode struct {
+	ID    int32 `bson:"_id"`
+	Space int64 `bson:"space"`
+}
+
+//CalcNode shards count of miner calculated from shards collection
+type CalcNode struct {
+	ID    int32 `bson:"_id"`
+	Space int64 `bson:"space"`
+	Check int64 `bson:"check"`
+}
+
+//CheckPoint ID of next shard to be counted
+type CheckPoint struct {
+	ID         int32 `bson:"_id"`
+	CheckPoint int64 `bson:"checkpoint"`
+}
+
+//EndPoint shards with ID not less than End are not counted, DryRun is set if checkpoints are saved by dry run
+type EndPoint struct {
+	ID     int32 `bson:"_id"`
+	End    int64 `bson:"end"`
+	DryRun bool  `bson:"dryRun"`
+}
+
+//Sequence sequence record in SN
+type Sequence struct {
+	ID  int32 `bson:"_id"`
+	Seq int   `bson:"seq"`
+}
+
+//UspaceCorrection options of correcting used space of miners by counting shards
+type UspaceCorrection struct {
+	//ShardCli client of database containing shards collection
+	ShardCli *mongo.Client
+	//ShardDB database name of shards collection
+	ShardDB string
+	//ShardCollection name of shards collection
+	ShardCollection string
+	//NodeCli client of database containing node collection to be corrected
+	NodeCli *mongo.Client
+	//NodeDB database name of node collection to be corrected
+	NodeDB string
+	//NodeCollection name of node collection to be corrected
+	NodeCollection string
+	//WorkDB database name of checkpoint and intermediate collections, on the same server of node collection
+	WorkDB string
+	//SNID SN whose used space will be corrected, uspaces.sn<SNID> field of nodes is corrected
+	SNID int
+	//UspaceKey key of uspaces to be corrected instead of sn<SNID>
+	UspaceKey string
+	//ResetDel set uspaces.del field of all nodes to 0 before counting
+	ResetDel bool
+	//BatchSize count of shards read between two checkpoints
+	BatchSize int64
+	//DryRun only calculate discrepancies without modifying node collection
+	DryRun bool
+}
+
+//UspaceDiscrepancy difference between used space of miner and calculated shards count
+type UspaceDiscrepancy struct {
+	MinerID    int32 `bson:"minerID" json:"minerID"`
+	Space      int64 `bson:"space" json:"space"`
+	Calculated int64 `bson:"calculated" json:"calculated"`
+	Applied    bool  `bson:"applied" json:"applied"`
+}
+
+//GetSNID read SN ID from sequence collection of SN database
+func GetSNID(ctx context.Context, cli *mongo.Client, db string) (int, error) {
+	sequence := new(Sequence)
+	err := cli.Database(db).Collection("Sequence").FindOne(ctx, bson.M{"_id": 101}).Decode(sequence)
+	if err != nil {
+		return -1, err
+	}
+	return sequence.Seq, nil
+}
+
+//CorrectUspace count shards of each miner and correct used space of SN on miners, progress is saved in
+//checkpoint collections of work database so that interrupted correction can be resumed, and work collections
+//are dropped after correction finished, checkpoints saved by dry run are never resumed by real correction and vice versa
+func CorrectUspace(ctx context.Context, opt *UspaceCorrection) ([]*UspaceDiscrepancy, error) {
+	entry := log.WithFields(log.Fields{Function: "CorrectUspace"})
+	shardsTab := opt.ShardCli.Database(opt.ShardDB).Collection(opt.ShardCollection)
+	nodeTab := opt.NodeCli.Database(opt.NodeDB).Collection(opt.NodeCollection)
+	workDB := opt.NodeCli.Database(opt.WorkDB)
+	checkPointTab := workDB.Collection(CheckPointTab)
+	endPointTab := workDB.Collection(EndPointTab)
+	calcNodeTab := workDB.Collection(CalcNodeTab)
+	tempNodeTab := workDB.Collection(TempNodeTab)
+	key := opt.UspaceKey
+	if key == "" {
+		key = fmt.Sprintf("sn%d", opt.SNID)
+	}
+	uspaceKey := fmt.Sprintf("uspaces.%s", key)
+
+	workTabs := []*mongo.Collection{checkPointTab, endPointTab, calcNodeTab, tempNodeTab}
+
+	entry.Info("1. read or create endpoint and temp nodes collection")
+	end := new(EndPoint)
+	err := endPointTab.FindOne(ctx, bson.M{"_id": 1}).Decode(end)
+	if err == nil && end.DryRun != opt.DryRun {
+		entry.Warnf("checkpoints are saved with dry run %t, counting shards from the beginning", end.DryRun)
+		err = dropCollections(ctx, workTabs)
+		if err != nil {
+			entry.WithError(err).Error("drop work collections failed")
+			return nil, err
+		}
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			entry.WithError(err).Error("read endpoint failed")
+			return nil, err
+		}
+		_, err := tempNodeTab.DeleteMany(ctx, bson.M{})
+		if err != nil {
+			entry.WithError(err).Error("delete all temp nodes failed")
+			return nil, err
+		}
+		cur, err := nodeTab.Find(ctx, bson.M{})
+		if err != nil {
+			entry.WithError(err).Error("read node collection failed")
+			return nil, err
+		}
+		for cur.Next(ctx) {
+			node := new(Node)
+			err := cur.Decode(node)
+			if err != nil {
+				cur.Close(ctx)
+				entry.WithError(err).Error("decode node failed")
+				return nil, err
+			}
+			_, err = tempNodeTab.InsertOne(ctx, &TempNode{ID: node.ID, Space: node.Uspaces[key]})
+			if err != nil {
+				cur.Close(ctx)
+				entry.WithError(err).Errorf("create temp node %d failed", node.ID)
+				return nil, err
+			}
+		}
+		cur.Close(ctx)
+		end.ID = 1
+		end.End = time.Now().Unix() << 32
+		end.DryRun = opt.DryRun
+		_, err = endPointTab.InsertOne(ctx, end)
+		if err != nil {
+			entry.WithError(err).Error("insert endpoint failed")
+			return nil, err
+		}
+		entry.Infof("insert endpoint: %d", end.End)
+		if !opt.DryRun && opt.ResetDel {
+			_, err = nodeTab.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"uspaces.del": 0}})
+			if err != nil {
+				entry.WithError(err).Error("clear uspaces.del field failed")
+				return nil, err
+			}
+			entry.Info("clear uspaces.del field of all nodes")
+		}
+	} else {
+		entry.Infof("read endpoint: %d", end.End)
+	}
+
+	entry.Info("2. read or create checkpoint")
+	check := new(CheckPoint)
+	err = checkPointTab.FindOne(ctx, bson.M{"_id": 1}).Decode(check)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			entry.WithError(err).Error("read checkpoint failed")
+			return nil, err
+		}
+		check.ID = 1
+		check.CheckPoint = 0
+		_, err = checkPointTab.InsertOne(ctx, check)
+		if err != nil {
+			entry.WithError(err).Error("insert checkpoint failed")
+			return nil, err
+		}
+		entry.Infof("insert checkpoint: %d", check.CheckPoint)
+	} else {
+		entry.Infof("read checkpoint: %d", check.CheckPoint)
+	}
+
+	entry.Info("3. calculate shards count")
+	total := 0
+	findOpts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(opt.BatchSize)
+	for {
+		var lastID int64
+		count := 0
+		cache := make(map[int32]int64)
+		cur, err := shardsTab.Find(ctx, bson.M{"_id": bson.M{"$gte": check.CheckPoint, "$lt": end.End}}, findOpts)
+		if err != nil {
+			entry.WithError(err).Error("read shards collection failed")
+			return nil, err
+		}
+		for cur.Next(ctx) {
+			count++
+			shard := new(Shard)
+			err := cur.Decode(shard)
+			if err != nil {
+				cur.Close(ctx)
+				entry.WithError(err).Error("decode shard failed")
+				return nil, err
+			}
+			if shard.NodeID != 0 {
+				cache[shard.NodeID]++
+			}
+			if shard.NodeID != shard.NodeID2 && shard.NodeID2 != 0 {
+				cache[shard.NodeID2]++
+			}
+			lastID = shard.ID
+		}
+		cur.Close(ctx)
+		if count == 0 {
+			break
+		}
+		total += count
+		entry.Infof("read %d shards", total)
+		for k, v := range cache {
+			res := calcNodeTab.FindOneAndUpdate(ctx, bson.M{"_id": k, "check": bson.M{"$lte": check.CheckPoint}}, bson.M{"$set": bson.M{"check": lastID + 1}, "$inc": bson.M{"space": v}})
+			if res.Err() != nil {
+				if res.Err() != mongo.ErrNoDocuments {
+					entry.WithError(res.Err()).Errorf("update count failed: %d->%d", k, v)
+					return nil, res.Err()
+				}
+				_, err := calcNodeTab.InsertOne(ctx, bson.M{"_id": k, "space": v, "check": lastID + 1})
+				if err != nil && !isDuplicateKeyError(err) {
+					entry.WithError(err).Errorf("insert calc node %d failed: %d", k, v)
+					return nil, err
+				}
+			}
+		}
+		_, err = checkPointTab.UpdateOne(ctx, bson.M{"_id": 1}, bson.M{"$set": bson.M{"checkpoint": lastID + 1}})
+		if err != nil {
+			entry.WithError(err).Errorf("update checkpoint failed: %d", lastID+1)
+			return nil, err
+		}
+		check.CheckPoint = lastID + 1
+		entry.Debugf("update checkpoint: %d", check.CheckPoint)
+	}
+
+	entry.Info("4. correct shards count of node")
+	discrepancies := make([]*UspaceDiscrepancy, 0)
+	cur, err := calcNodeTab.Find(ctx, bson.M{})
+	if err != nil {
+		entry.WithError(err).Error("read calcnode collection failed")
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		calcnode := new(CalcNode)
+		err := cur.Decode(calcnode)
+		if err != nil {
+			entry.WithError(err).Error("decode calc node failed")
+			return discrepancies, err
+		}
+		tempnode := new(TempNode)
+		err = tempNodeTab.FindOne(ctx, bson.M{"_id": calcnode.ID}).Decode(tempnode)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				entry.Warnf("cannot find temp node %d", calcnode.ID)
+				continue
+			}
+			entry.WithError(err).Errorf("read temp node error: %d", calcnode.ID)
+			return discrepancies, err
+		}
+		if calcnode.Space == tempnode.Space {
+			continue
+		}
+		discrepancy := &UspaceDiscrepancy{MinerID: calcnode.ID, Space: tempnode.Space, Calculated: calcnode.Space}
+		discrepancies = append(discrepancies, discrepancy)
+		if opt.DryRun {
+			continue
+		}
+		res := nodeTab.FindOneAndUpdate(ctx, bson.M{"_id": calcnode.ID, "correct": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"correct": true}, "$inc": bson.M{uspaceKey: calcnode.Space - tempnode.Space}})
+		if res.Err() != nil {
+			if res.Err() == mongo.ErrNoDocuments {
+				entry.Debugf("skip updating node %d when update uspace", calcnode.ID)
+				continue
+			}
+			entry.WithError(res.Err()).Errorf("update uspace of node %d failed", calcnode.ID)
+			return discrepancies, res.Err()
+		}
+		discrepancy.Applied = true
+		entry.Infof("update uspace of node %d: %d", calcnode.ID, calcnode.Space-tempnode.Space)
+	}
+	if !opt.DryRun {
+		_, err = nodeTab.UpdateMany(ctx, bson.M{"correct": bson.M{"$exists": true}}, bson.M{"$unset": bson.M{"correct": true}})
+		if err != nil {
+			entry.WithError(err).Error("remove correct field failed")
+			return discrepancies, err
+		}
+		entry.Info("remove correct field of nodes")
+	}
+	err = dropCollections(ctx, workTabs)
+	if err != nil {
+		entry.WithError(err).Error("drop work collections failed")
+		return discrepancies, err
+	}
+	entry.Info("correction finished")
+	return discrepancies, nil
+}
+
+func dropCollections(ctx context.Context, tabs []*mongo.Collection) error {
+	for _, tab := range tabs {
+		err := tab.Drop(ctx)
+		if err != nil {
+			return fmt.Errorf("drop collection %s: %s", tab.Name(), err)
+		}
+	}
+	return nil
+}
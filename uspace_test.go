@@ -0,0 +1,69 @@
+package yttracker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//TestCorrectUspaceChangedAfterSnapshot used space changed by SN after snapshot is taken must be kept, only the
+//difference between calculated shards count and snapshot is added
+func TestCorrectUspaceChangedAfterSnapshot(t *testing.T) {
+	nodeTab, drop := testCollection(t)
+	defer drop()
+	ctx := context.Background()
+	db := nodeTab.Database()
+	shardsTab := db.Collection(nodeTab.Name() + "_shards")
+	defer shardsTab.Drop(ctx)
+	workDB := db.Client().Database(fmt.Sprintf("yttracker_test_work_%d", time.Now().UnixNano()))
+	defer workDB.Drop(ctx)
+
+	end := time.Now().Unix() << 32
+	for i := int64(0); i < 8; i++ {
+		if _, err := shardsTab.InsertOne(ctx, &Shard{ID: i, NodeID: 1, NodeID2: 1}); err != nil {
+			t.Fatalf("inserting shard: %s", err)
+		}
+	}
+	//snapshot taken by an interrupted run when uspace was 5
+	if _, err := workDB.Collection(EndPointTab).InsertOne(ctx, &EndPoint{ID: 1, End: end}); err != nil {
+		t.Fatalf("inserting endpoint: %s", err)
+	}
+	if _, err := workDB.Collection(TempNodeTab).InsertOne(ctx, &TempNode{ID: 1, Space: 5}); err != nil {
+		t.Fatalf("inserting temp node: %s", err)
+	}
+	//SN stored 2 more shards after snapshot, which are not counted
+	if _, err := nodeTab.InsertOne(ctx, bson.M{"_id": 1, "uspaces": bson.M{"sn0": 7}}); err != nil {
+		t.Fatalf("inserting node: %s", err)
+	}
+
+	discrepancies, err := CorrectUspace(ctx, &UspaceCorrection{
+		ShardCli:        db.Client(),
+		ShardDB:         db.Name(),
+		ShardCollection: shardsTab.Name(),
+		NodeCli:         db.Client(),
+		NodeDB:          db.Name(),
+		NodeCollection:  nodeTab.Name(),
+		WorkDB:          workDB.Name(),
+		SNID:            0,
+		BatchSize:       3,
+	})
+	if err != nil {
+		t.Fatalf("correcting uspace: %s", err)
+	}
+	if len(discrepancies) != 1 || !discrepancies[0].Applied || discrepancies[0].Space != 5 || discrepancies[0].Calculated != 8 {
+		t.Fatalf("unexpected discrepancies: %+v", discrepancies)
+	}
+	node := new(Node)
+	if err := nodeTab.FindOne(ctx, bson.M{"_id": 1}).Decode(node); err != nil {
+		t.Fatalf("reading node: %s", err)
+	}
+	if node.Uspaces["sn0"] != 10 {
+		t.Fatalf("uspaces.sn0 is %d, expected 10", node.Uspaces["sn0"])
+	}
+	if n, err := nodeTab.CountDocuments(ctx, bson.M{"correct": bson.M{"$exists": true}}); err != nil || n != 0 {
+		t.Fatalf("correct marker is left on %d nodes: %v", n, err)
+	}
+}
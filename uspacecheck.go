@@ -0,0 +1,65 @@
+package yttracker
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//UspaceDiff miner whose sum of uspaces differs from its used space or saved total
+type UspaceDiff struct {
+	MinerID     int32 `json:"minerID"`
+	UsedSpace   int64 `json:"usedSpace"`
+	UspaceTotal int64 `json:"uspaceTotal"`
+	Sum         int64 `json:"sum"`
+	Diff        int64 `json:"diff"`
+}
+
+//UspaceCheckResult result of uspaces consistency check
+type UspaceCheckResult struct {
+	Checked      int64         `json:"checked"`
+	Inconsistent []*UspaceDiff `json:"inconsistent"`
+}
+
+//UspaceCheckHandler compare sum of uspaces with usedSpace of each miner, miners whose difference is greater than
+//tolerance or whose uspaceTotal is not the sum of uspaces are reported
+func (tracker *MinerTracker) UspaceCheckHandler(c echo.Context) error {
+	entry := log.WithFields(log.Fields{Component: ComponentHTTP, Function: "UspaceCheckHandler"})
+	var tolerance int64
+	if str := c.QueryParam("tolerance"); str != "" {
+		t, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || t < 0 {
+			entry.Errorf("invalid param %s", str)
+			return c.String(http.StatusBadRequest, "invalid tolerance")
+		}
+		tolerance = t
+	}
+	collection := tracker.dbCli.Database(MinerTrackerDB).Collection(NodeTab)
+	cur, err := collection.Find(context.Background(), bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "usedSpace": 1, "uspaces": 1, "uspaceTotal": 1}).SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		entry.WithError(err).Error("finding used spaces of miners")
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	defer cur.Close(context.Background())
+	result := &UspaceCheckResult{Inconsistent: make([]*UspaceDiff, 0)}
+	for cur.Next(context.Background()) {
+		node := new(Node)
+		err := cur.Decode(node)
+		if err != nil {
+			entry.WithError(err).Error("decoding used spaces of miner")
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		result.Checked++
+		sum := node.UspacesSum()
+		diff := node.UsedSpace - sum
+		if diff > tolerance || diff < -tolerance || sum != node.UspaceTotal {
+			result.Inconsistent = append(result.Inconsistent, &UspaceDiff{MinerID: node.ID, UsedSpace: node.UsedSpace, UspaceTotal: node.UspaceTotal, Sum: sum, Diff: diff})
+		}
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -2,8 +2,10 @@ package yttracker
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"reflect"
+	"time"
 )
 
 // EqualSorted check if two arrays are equal
@@ -76,6 +78,18 @@ func Max(num ...int64) int64 {
 	return max
 }
 
+//sleepContext sleep for duration d, returns false if ctx is done before d elapsed
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Min select minimum value
 func Min(num ...int64) int64 {
 	min := num[0]
@@ -0,0 +1,342 @@
+package yttracker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	ytcrypto "github.com/yottachain/YTCrypto"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+//ConfigError all problems found when validating configuration
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(e.Problems, "; "))
+}
+
+func (e *ConfigError) addf(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+//Validate check all sections of configuration, returns a ConfigError containing all problems found, or nil if valid
+func (config *Config) Validate() error {
+	e := new(ConfigError)
+	checkBindAddr(e, HTTPBindAddrField, config.HTTPBindAddr)
+	checkURL(e, EOSURLField, config.EOSURL, "http", "https")
+	if _, err := connstring.Parse(config.MongoDBURL); err != nil {
+		e.addf("%s: invalid mongoDB URL %q: %s", MongoDBURLField, config.MongoDBURL, err)
+	}
+	if config.AuraMQ == nil || config.AuraMQ.ServerConfig == nil {
+		e.addf("auramq.server: section is missing")
+	} else {
+		conf := config.AuraMQ.ServerConfig
+		checkBindAddr(e, AuramqServerBindAddrField, conf.BindAddr)
+		checkPositive(e, AuramqServerRouterBufferSizeField, conf.RouterBufferSize)
+		checkPositive(e, AuramqServerSubscriberBufferSizeField, conf.SubscriberBufferSize)
+		checkPositive(e, AuramqServerReadBufferSizeField, conf.ReadBufferSize)
+		checkPositive(e, AuramqServerWriteBufferSizeField, conf.WriteBufferSize)
+		checkPositive(e, AuramqServerPingWaitField, conf.PingWait)
+		checkPositive(e, AuramqServerReadWaitField, conf.ReadWait)
+		checkPositive(e, AuramqServerWriteWaitField, conf.WriteWait)
+		checkNotEmpty(e, AuramqServerMinerSyncTopicField, conf.MinerSyncTopic)
+	}
+	if config.AuraMQ == nil || config.AuraMQ.ClientConfig == nil {
+		e.addf("auramq.client: section is missing")
+	} else {
+		conf := config.AuraMQ.ClientConfig
+		checkPositive(e, AuramqClientSubscriberBufferSizeField, conf.SubscriberBufferSize)
+		checkPositive(e, AuramqClientPingWaitField, conf.PingWait)
+		checkPositive(e, AuramqClientReadWaitField, conf.ReadWait)
+		checkPositive(e, AuramqClientWriteWaitField, conf.WriteWait)
+		checkNotEmpty(e, AuramqClientMinerSyncTopicField, conf.MinerSyncTopic)
+		checkEndpoints(e, AuramqClientAllSNURLsField, conf.AllSNURLs, "ws", "wss")
+		checkNotEmpty(e, AuramqClientAccountField, conf.Account)
+		if conf.PrivateKey == "" {
+			e.addf("%s: cannot be empty", AuramqClientPrivateKeyField)
+		} else if _, err := ytcrypto.GetPublicKeyByPrivateKey(conf.PrivateKey); err != nil {
+			e.addf("%s: invalid private key: %s", AuramqClientPrivateKeyField, err)
+		}
+		checkNotEmpty(e, AuramqClientClientIDField, conf.ClientID)
+	}
+	if config.AuraMQ == nil || config.AuraMQ.Federation == nil {
+		e.addf("auramq.federation: section is missing")
+	} else {
+		conf := config.AuraMQ.Federation
+		for _, peer := range conf.Peers {
+			checkURL(e, AuramqFederationPeersField, peer, "ws", "wss")
+		}
+		checkNotEmpty(e, AuramqFederationTopicField, conf.Topic)
+		if config.AuraMQ.ServerConfig != nil && conf.Topic == config.AuraMQ.ServerConfig.MinerSyncTopic {
+			e.addf("%s: cannot be the same as %s", AuramqFederationTopicField, AuramqServerMinerSyncTopicField)
+		}
+		checkPositive(e, AuramqFederationMaxHopsField, conf.MaxHops)
+		checkPositive(e, AuramqFederationDedupWindowField, conf.DedupWindow)
+	}
+	if config.AuraMQ == nil || config.AuraMQ.Snapshot == nil {
+		e.addf("auramq.snapshot: section is missing")
+	} else {
+		conf := config.AuraMQ.Snapshot
+		checkNotEmpty(e, AuramqSnapshotTopicField, conf.Topic)
+		if config.AuraMQ.ServerConfig != nil && conf.Topic == config.AuraMQ.ServerConfig.MinerSyncTopic {
+			e.addf("%s: cannot be the same as %s", AuramqSnapshotTopicField, AuramqServerMinerSyncTopicField)
+		}
+		if config.AuraMQ.Federation != nil && conf.Topic == config.AuraMQ.Federation.Topic {
+			e.addf("%s: cannot be the same as %s", AuramqSnapshotTopicField, AuramqFederationTopicField)
+		}
+		checkPositive(e, AuramqSnapshotBatchSizeField, conf.BatchSize)
+		if conf.BatchInterval < 0 {
+			e.addf("%s: cannot be negative, got %d", AuramqSnapshotBatchIntervalField, conf.BatchInterval)
+		}
+	}
+	if config.AuraMQ == nil || config.AuraMQ.Replay == nil {
+		e.addf("auramq.replay: section is missing")
+	} else {
+		conf := config.AuraMQ.Replay
+		checkNotEmpty(e, AuramqReplayTopicField, conf.Topic)
+		checkNotEmpty(e, AuramqReplayRequestTopicField, conf.RequestTopic)
+		if conf.Topic != "" && conf.Topic == conf.RequestTopic {
+			e.addf("%s: cannot be the same as %s", AuramqReplayRequestTopicField, AuramqReplayTopicField)
+		}
+		topics := make(map[string]string)
+		if config.AuraMQ.ServerConfig != nil {
+			topics[config.AuraMQ.ServerConfig.MinerSyncTopic] = AuramqServerMinerSyncTopicField
+		}
+		if config.AuraMQ.Federation != nil {
+			topics[config.AuraMQ.Federation.Topic] = AuramqFederationTopicField
+		}
+		if config.AuraMQ.Snapshot != nil {
+			topics[config.AuraMQ.Snapshot.Topic] = AuramqSnapshotTopicField
+		}
+		if other, ok := topics[conf.Topic]; ok && conf.Topic != "" {
+			e.addf("%s: cannot be the same as %s", AuramqReplayTopicField, other)
+		}
+		if other, ok := topics[conf.RequestTopic]; ok && conf.RequestTopic != "" {
+			e.addf("%s: cannot be the same as %s", AuramqReplayRequestTopicField, other)
+		}
+		checkPositive(e, AuramqReplayMaxSizeField, conf.MaxSize)
+		if conf.MaxCount < 0 {
+			e.addf("%s: cannot be negative, got %d", AuramqReplayMaxCountField, conf.MaxCount)
+		}
+		checkPositive(e, AuramqReplayBatchSizeField, conf.BatchSize)
+	}
+	if config.MinerStat == nil {
+		e.addf("miner-stat: section is missing")
+	} else {
+		conf := config.MinerStat
+		checkEndpoints(e, MinerStatAllSyncURLsField, conf.AllSyncURLs, "http", "https")
+		checkPositive(e, MinerStatBatchSizeField, conf.BatchSize)
+		checkPositive(e, MinerStatWaitTimeField, conf.WaitTime)
+		if conf.SkipTime < 0 {
+			e.addf("%s: cannot be negative, got %d", MinerStatSkipTimeField, conf.SkipTime)
+		}
+	}
+	if config.Logger == nil {
+		e.addf("logger: section is missing")
+	} else {
+		conf := config.Logger
+		outputs := conf.Outputs()
+		if len(outputs) == 0 {
+			e.addf("%s: cannot be empty", LoggerOutputField)
+		}
+		for _, output := range outputs {
+			switch output {
+			case "file":
+				checkNotEmpty(e, LoggerFilePathField, conf.FilePath)
+				checkPositive(e, LoggerRotationTimeField, int(conf.RotationTime))
+				checkPositive(e, LoggerMaxAgeField, int(conf.MaxAge))
+			case "stdout":
+			default:
+				e.addf("%s: must be stdout, file or both separated by comma, got %q", LoggerOutputField, output)
+			}
+		}
+		if _, err := log.ParseLevel(conf.Level); err != nil {
+			e.addf("%s: must be one of trace, debug, info, warn, error, fatal, panic, got %q", LoggerLevelField, conf.Level)
+		}
+		switch strings.ToLower(conf.Format) {
+		case "", "text", "json":
+		default:
+			e.addf("%s: must be text or json, got %q", LoggerFormatField, conf.Format)
+		}
+		names := make([]string, 0, len(conf.Levels))
+		for component := range conf.Levels {
+			names = append(names, component)
+		}
+		sort.Strings(names)
+		for _, component := range names {
+			level := conf.Levels[component]
+			if !isComponent(component) {
+				e.addf("%s: no such component %q, must be one of %s", LoggerLevelsField, component, strings.Join(components, ", "))
+			} else if _, err := log.ParseLevel(level); err != nil {
+				e.addf("%s.%s: must be one of trace, debug, info, warn, error, fatal, panic, got %q", LoggerLevelsField, component, level)
+			}
+		}
+	}
+	if config.Alert == nil {
+		e.addf("alert: section is missing")
+	} else {
+		conf := config.Alert
+		if conf.WebhookURL != "" {
+			checkURL(e, AlertWebhookURLField, conf.WebhookURL, "http", "https")
+		}
+		checkPositive(e, AlertTimeoutField, conf.Timeout)
+		if conf.Retries < 0 {
+			e.addf("%s: cannot be negative, got %d", AlertRetriesField, conf.Retries)
+		}
+		checkPositive(e, AlertRetryIntervalField, conf.RetryInterval)
+		checkPositive(e, AlertCheckIntervalField, conf.CheckInterval)
+		names := make(map[string]bool)
+		for i, rule := range conf.Rules {
+			if rule == nil {
+				e.addf("%s[%d]: rule is empty", AlertRulesField, i)
+				continue
+			}
+			if err := rule.Check(); err != nil {
+				e.addf("%s[%d]: %s", AlertRulesField, i, err)
+			}
+			if names[rule.Name] {
+				e.addf("%s[%d]: duplicate rule name %q", AlertRulesField, i, rule.Name)
+			}
+			names[rule.Name] = true
+			if rule.Webhook == "" && conf.WebhookURL == "" {
+				e.addf("%s[%d]: webhook must be set when %s is empty", AlertRulesField, i, AlertWebhookURLField)
+			}
+		}
+	}
+	if config.Offline == nil {
+		e.addf("offline: section is missing")
+	} else {
+		conf := config.Offline
+		checkPositive(e, OfflineThresholdField, conf.Threshold)
+		checkPositive(e, OfflineIntervalField, conf.Interval)
+		checkNotEmpty(e, OfflineTopicField, conf.Topic)
+		if mq := config.AuraMQ; mq != nil && conf.Topic != "" {
+			topics := make([][2]string, 0)
+			if mq.ServerConfig != nil {
+				topics = append(topics, [2]string{AuramqServerMinerSyncTopicField, mq.ServerConfig.MinerSyncTopic})
+			}
+			if mq.Federation != nil {
+				topics = append(topics, [2]string{AuramqFederationTopicField, mq.Federation.Topic})
+			}
+			if mq.Snapshot != nil {
+				topics = append(topics, [2]string{AuramqSnapshotTopicField, mq.Snapshot.Topic})
+			}
+			if mq.Replay != nil {
+				topics = append(topics, [2]string{AuramqReplayTopicField, mq.Replay.Topic}, [2]string{AuramqReplayRequestTopicField, mq.Replay.RequestTopic})
+			}
+			for _, topic := range topics {
+				if conf.Topic == topic[1] {
+					e.addf("%s: cannot be the same as %s", OfflineTopicField, topic[0])
+				}
+			}
+		}
+	}
+	if config.Reconcile == nil {
+		e.addf("reconcile: section is missing")
+	} else {
+		conf := config.Reconcile
+		if err := CheckSNEndpoints(conf.SNURLs); err != nil {
+			e.addf("%s: %s", ReconcileSNURLsField, err)
+		}
+		for _, ep := range conf.SNURLs {
+			if ep == nil {
+				continue
+			}
+			//URL is not shown since it may contain password
+			if _, err := connstring.Parse(ep.URL); err != nil {
+				e.addf("%s[SN%d]: invalid mongoDB URL", ReconcileSNURLsField, ep.ID)
+			}
+		}
+		checkPositive(e, ReconcileIntervalField, conf.Interval)
+		checkPositive(e, ReconcileBatchSizeField, conf.BatchSize)
+		checkNotEmpty(e, ReconcileShardDBField, conf.ShardDB)
+		checkNotEmpty(e, ReconcileShardTabField, conf.ShardCollection)
+		checkNotEmpty(e, ReconcileWorkDBField, conf.WorkDB)
+	}
+	indexNames := make(map[string]bool)
+	for _, spec := range declaredIndexes {
+		indexNames[spec.Collection+"."+spec.IndexName()] = true
+	}
+	for i, spec := range config.Indexes {
+		if spec == nil {
+			e.addf("%s[%d]: index cannot be empty", IndexesField, i)
+			continue
+		}
+		if err := spec.Check(); err != nil {
+			e.addf("%s[%d]: %s", IndexesField, i, err)
+			continue
+		}
+		name := spec.Collection + "." + spec.IndexName()
+		if indexNames[name] {
+			e.addf("%s[%d]: duplicate index name %q of collection %s", IndexesField, i, spec.IndexName(), spec.Collection)
+		}
+		indexNames[name] = true
+	}
+	if config.Misc == nil {
+		e.addf("misc: section is missing")
+	} else {
+		conf := config.Misc
+		checkPositive(e, MiscRefreshAuthIntervalField, conf.RefreshAuthInterval)
+		checkPositive(e, MiscRefreshAuthWorkersField, conf.RefreshAuthWorkers)
+		checkPositive(e, MiscLeaseTTLField, conf.LeaseTTL)
+		if conf.AuthNegativeTTL < 0 {
+			e.addf("%s: cannot be negative, got %d", MiscAuthNegativeTTLField, conf.AuthNegativeTTL)
+		}
+	}
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+func checkNotEmpty(e *ConfigError, field, value string) {
+	if strings.TrimSpace(value) == "" {
+		e.addf("%s: cannot be empty", field)
+	}
+}
+
+func checkPositive(e *ConfigError, field string, value int) {
+	if value <= 0 {
+		e.addf("%s: must be greater than 0, got %d", field, value)
+	}
+}
+
+func checkBindAddr(e *ConfigError, field, addr string) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		e.addf("%s: invalid binding address %q: %s", field, addr, err)
+	}
+}
+
+func checkURL(e *ConfigError, field, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		e.addf("%s: invalid URL %q: %s", field, value, err)
+		return
+	}
+	if u.Host == "" {
+		e.addf("%s: URL %q has no host", field, value)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return
+		}
+	}
+	e.addf("%s: scheme of URL %q must be one of %s", field, value, strings.Join(schemes, ", "))
+}
+
+func checkEndpoints(e *ConfigError, field string, endpoints []*SNEndpoint, schemes ...string) {
+	if err := CheckSNEndpoints(endpoints); err != nil {
+		e.addf("%s: %s", field, err)
+	}
+	for _, ep := range endpoints {
+		if ep != nil {
+			checkURL(e, fmt.Sprintf("%s[SN%d]", field, ep.ID), ep.URL, schemes...)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package yttracker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aurawing/auramq/msg"
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/websocket"
+)
+
+//wsSubscriber websocket client of remote MQ server which only receives messages of subscribed topics, it speaks the
+//same protocol as client of auramq but owns the connection, so that it can be closed at any time by Close
+type wsSubscriber struct {
+	conn      *websocket.Conn
+	callback  func(*msg.Message)
+	pingWait  time.Duration
+	readWait  time.Duration
+	writeWait time.Duration
+	closeOnce sync.Once
+}
+
+//dialSubscriber connect to MQ server, authenticate by authReq and subscribe topics
+func dialSubscriber(wsurl string, callback func(*msg.Message), authReq *msg.AuthReq, topics []string, pingWait, readWait, writeWait int) (*wsSubscriber, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(wsurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &wsSubscriber{conn: conn, callback: callback, pingWait: time.Duration(pingWait) * time.Second, readWait: time.Duration(readWait) * time.Second, writeWait: time.Duration(writeWait) * time.Second}
+	err = c.request(authReq, "auth failed")
+	if err == nil {
+		err = c.request(&msg.SubscribeReq{Topics: topics}, "client ID conflict")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+//request send one request and wait for its ack, returns error with reason if request is rejected
+func (c *wsSubscriber) request(req proto.Message, reason string) error {
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeWait))
+	err = c.conn.WriteMessage(websocket.BinaryMessage, b)
+	if err != nil {
+		return err
+	}
+	c.conn.SetReadDeadline(time.Now().Add(c.readWait))
+	_, b, err = c.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	ack := new(msg.Ack)
+	err = proto.Unmarshal(b, ack)
+	if err != nil {
+		return err
+	}
+	if !ack.Ack {
+		return errors.New(reason)
+	}
+	return nil
+}
+
+//Run receive messages and ping server until connection is closed by either side
+func (c *wsSubscriber) Run() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.pingWait)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				//control messages can be written concurrently with reading
+				err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.writeWait))
+				if err != nil {
+					c.Close()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	c.conn.SetReadDeadline(time.Now().Add(c.readWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(c.readWait))
+		return nil
+	})
+	for {
+		_, b, err := c.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		m := new(msg.Message)
+		if err := proto.Unmarshal(b, m); err != nil {
+			continue
+		}
+		c.callback(m)
+	}
+	close(done)
+	c.Close()
+}
+
+//Close close connection, it is safe to be called more than once and while Run is running
+func (c *wsSubscriber) Close() {
+	c.closeOnce.Do(func() {
+		c.conn.Close()
+	})
+}